@@ -0,0 +1,356 @@
+// Package actionstest runs action code in-process against a sandboxed copy of
+// the environment the runner provides, then lets tests inspect what the
+// action did.
+//
+//	func TestAction(t *testing.T) {
+//		h := actionstest.New(t, actionstest.Options{
+//			Inputs: map[string]string{"name": "world"},
+//		})
+//		run()
+//		h.AssertOutput("greeting", "Hello world")
+//		h.AssertAnnotation(actionstest.AnnotationWarning, "name is deprecated")
+//	}
+//
+// The sandbox uses t.Setenv, so tests using it can't call t.Parallel.
+package actionstest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	core "github.com/jcbhmr/go-toolkit/actionscore"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/command"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/filecommand"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/summary"
+)
+
+type Options struct {
+	// Each input is exposed as INPUT_<NAME> the same way the runner does it:
+	// upper-cased with spaces replaced by underscores.
+	Inputs map[string]string
+	// Extra environment variables, set after the sandbox's GITHUB_* files.
+	Env map[string]string
+}
+
+// Harness is a per-test sandbox. All file commands go to files in a
+// temporary directory and workflow commands are captured instead of being
+// printed.
+type Harness struct {
+	t   testing.TB
+	dir string
+
+	envFile     string
+	outputFile  string
+	stateFile   string
+	pathFile    string
+	summaryFile string
+
+	mu       sync.Mutex
+	stdout   bytes.Buffer
+	ran      bool
+	exitCode core.ExitCode
+	runErr   error
+}
+
+func New(t testing.TB, options Options) *Harness {
+	t.Helper()
+	h := &Harness{t: t, dir: t.TempDir()}
+
+	files := []struct {
+		env  string
+		path *string
+	}{
+		{"GITHUB_ENV", &h.envFile},
+		{"GITHUB_OUTPUT", &h.outputFile},
+		{"GITHUB_STATE", &h.stateFile},
+		{"GITHUB_PATH", &h.pathFile},
+		{summary.SummaryEnvVar, &h.summaryFile},
+	}
+	for _, file := range files {
+		*file.path = filepath.Join(h.dir, strings.ToLower(file.env))
+		err := os.WriteFile(*file.path, nil, 0666)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv(file.env, *file.path)
+	}
+
+	for name, value := range options.Inputs {
+		t.Setenv(InputEnvName(name), value)
+	}
+	for name, value := range options.Env {
+		t.Setenv(name, value)
+	}
+
+	t.Cleanup(command.SetOutput(writerFunc(h.write)))
+	return h
+}
+
+// InputEnvName returns the environment variable the runner uses for an
+// action input.
+func InputEnvName(name string) string {
+	return "INPUT_" + strings.ToUpper(strings.ReplaceAll(name, " ", "_"))
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+func (h *Harness) write(p []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.stdout.Write(p)
+}
+
+// Dir is the temporary directory holding the sandbox's files. Actions can use
+// it as a workspace.
+func (h *Harness) Dir() string {
+	return h.dir
+}
+
+// ExitCode returns the exit code recorded by the last Run.
+func (h *Harness) ExitCode() core.ExitCode {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.exitCode
+}
+
+// Stdout returns everything written through the command package.
+func (h *Harness) Stdout() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.stdout.String()
+}
+
+type Command = command.Command
+
+// Commands returns the workflow commands issued so far, in order.
+func (h *Harness) Commands() []Command {
+	var commands []Command
+	for _, line := range strings.Split(h.Stdout(), "\n") {
+		if cmd, ok := command.Parse(line); ok {
+			commands = append(commands, cmd)
+		}
+	}
+	return commands
+}
+
+func (h *Harness) readFile(path string) string {
+	h.t.Helper()
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return string(bytes)
+}
+
+// keyValues merges a file command file with the equivalent legacy command
+// (set-output, set-env, save-state), in the order the runner would apply them.
+func (h *Harness) keyValues(path string, legacyCommand string) map[string]string {
+	h.t.Helper()
+	values := map[string]string{}
+	for _, cmd := range h.Commands() {
+		if cmd.Command == legacyCommand {
+			values[cmd.Properties["name"]] = cmd.Message
+		}
+	}
+	messages, err := filecommand.ParseKeyValueMessages(h.readFile(path))
+	if err != nil {
+		h.t.Fatalf("parsing %s: %v", filepath.Base(path), err)
+	}
+	for _, message := range messages {
+		values[message.Key] = message.Value
+	}
+	return values
+}
+
+// Outputs returns the step outputs set through GITHUB_OUTPUT or set-output.
+func (h *Harness) Outputs() map[string]string {
+	h.t.Helper()
+	return h.keyValues(h.outputFile, "set-output")
+}
+
+// ExportedVars returns the variables exported through GITHUB_ENV or set-env.
+func (h *Harness) ExportedVars() map[string]string {
+	h.t.Helper()
+	return h.keyValues(h.envFile, "set-env")
+}
+
+// State returns the values saved through GITHUB_STATE or save-state.
+func (h *Harness) State() map[string]string {
+	h.t.Helper()
+	return h.keyValues(h.stateFile, "save-state")
+}
+
+// Paths returns the directories added to PATH, in the order they were added.
+func (h *Harness) Paths() []string {
+	h.t.Helper()
+	var paths []string
+	for _, cmd := range h.Commands() {
+		if cmd.Command == "add-path" {
+			paths = append(paths, cmd.Message)
+		}
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(h.readFile(h.pathFile), "\r\n", "\n"), "\n") {
+		if line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths
+}
+
+// Masks returns the values registered with add-mask.
+func (h *Harness) Masks() []string {
+	var masks []string
+	for _, cmd := range h.Commands() {
+		if cmd.Command == "add-mask" {
+			masks = append(masks, cmd.Message)
+		}
+	}
+	return masks
+}
+
+type AnnotationLevel string
+
+const (
+	AnnotationError   AnnotationLevel = "error"
+	AnnotationWarning AnnotationLevel = "warning"
+	AnnotationNotice  AnnotationLevel = "notice"
+)
+
+type Annotation struct {
+	Level       AnnotationLevel
+	Message     string
+	Title       string
+	File        string
+	StartLine   string
+	EndLine     string
+	StartColumn string
+	EndColumn   string
+}
+
+// Annotations returns the error, warning and notice commands issued so far.
+func (h *Harness) Annotations() []Annotation {
+	var annotations []Annotation
+	for _, cmd := range h.Commands() {
+		switch level := AnnotationLevel(cmd.Command); level {
+		case AnnotationError, AnnotationWarning, AnnotationNotice:
+			annotations = append(annotations, Annotation{
+				Level:       level,
+				Message:     cmd.Message,
+				Title:       cmd.Properties["title"],
+				File:        cmd.Properties["file"],
+				StartLine:   cmd.Properties["line"],
+				EndLine:     cmd.Properties["endLine"],
+				StartColumn: cmd.Properties["col"],
+				EndColumn:   cmd.Properties["endColumn"],
+			})
+		}
+	}
+	return annotations
+}
+
+// Summary returns the contents of GITHUB_STEP_SUMMARY.
+func (h *Harness) Summary() string {
+	h.t.Helper()
+	return h.readFile(h.summaryFile)
+}
+
+// SummaryHTML returns the job summary rendered as a standalone HTML page.
+func (h *Harness) SummaryHTML() string {
+	h.t.Helper()
+	return summary.RenderHTML(h.Summary(), summary.SummaryRenderOptions{})
+}
+
+func (h *Harness) AssertOutput(name string, expected string) {
+	h.t.Helper()
+	assertValue(h.t, "output", h.Outputs(), name, expected)
+}
+
+func (h *Harness) AssertExportedVar(name string, expected string) {
+	h.t.Helper()
+	assertValue(h.t, "exported variable", h.ExportedVars(), name, expected)
+}
+
+func (h *Harness) AssertState(name string, expected string) {
+	h.t.Helper()
+	assertValue(h.t, "state", h.State(), name, expected)
+}
+
+func assertValue(t testing.TB, kind string, values map[string]string, name string, expected string) {
+	t.Helper()
+	got, ok := values[name]
+	if !ok {
+		t.Errorf("expected %s %q to be set to %q, but it was not set", kind, name, expected)
+	} else if got != expected {
+		t.Errorf("expected %s %q to be %q, got %q", kind, name, expected, got)
+	}
+}
+
+func (h *Harness) AssertPath(path string) {
+	h.t.Helper()
+	paths := h.Paths()
+	if !slices.Contains(paths, path) {
+		h.t.Errorf("expected %q to be added to PATH, got %q", path, paths)
+	}
+}
+
+func (h *Harness) AssertMasked(secret string) {
+	h.t.Helper()
+	masks := h.Masks()
+	if !slices.Contains(masks, secret) {
+		h.t.Errorf("expected %q to be masked, got %q", secret, masks)
+	}
+}
+
+// AssertAnnotation checks that an annotation with the given level and message
+// was issued.
+func (h *Harness) AssertAnnotation(level AnnotationLevel, message string) {
+	h.t.Helper()
+	annotations := h.Annotations()
+	for _, annotation := range annotations {
+		if annotation.Level == level && annotation.Message == message {
+			return
+		}
+	}
+	h.t.Errorf("expected %s annotation %q, got %s", level, message, formatAnnotations(annotations))
+}
+
+func formatAnnotations(annotations []Annotation) string {
+	if len(annotations) == 0 {
+		return "none"
+	}
+	var parts []string
+	for _, annotation := range annotations {
+		parts = append(parts, fmt.Sprintf("%s %q", annotation.Level, annotation.Message))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// AssertSummaryContains checks that the job summary contains substr.
+func (h *Harness) AssertSummaryContains(substr string) {
+	h.t.Helper()
+	got := h.Summary()
+	if !strings.Contains(got, substr) {
+		h.t.Errorf("expected summary to contain %q, got %q", substr, got)
+	}
+}
+
+// AssertSummaryHTMLContains checks that the rendered job summary contains
+// substr, which is useful for markdown that only turns into HTML when
+// rendered.
+func (h *Harness) AssertSummaryHTMLContains(substr string) {
+	h.t.Helper()
+	got := h.SummaryHTML()
+	if !strings.Contains(got, substr) {
+		h.t.Errorf("expected rendered summary to contain %q, got %q", substr, got)
+	}
+}
@@ -0,0 +1,156 @@
+package actionstest_test
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	core "github.com/jcbhmr/go-toolkit/actionscore"
+	"github.com/jcbhmr/go-toolkit/actionscore/actionstest"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/command"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/filecommand"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/summary"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/utils"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestHarness(t *testing.T) {
+	h := actionstest.New(t, actionstest.Options{
+		Inputs: map[string]string{"who to greet": "Mona"},
+	})
+
+	if got := os.Getenv("INPUT_WHO_TO_GREET"); got != "Mona" {
+		t.Errorf("expected %q, got %q", "Mona", got)
+	}
+
+	err := core.ExportVariable("GREETING", "hello\nworld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = core.SetSecret("hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := filecommand.PrepareKeyValueMessage("time", "noon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = filecommand.IssueFileCommand("OUTPUT", message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = filecommand.IssueFileCommand("PATH", "/opt/tool/bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = command.IssueCommand("warning", utils.ToCommandProperties(utils.InternalCoreAnnotationProperties{
+		File:      ptr("main.go"),
+		StartLine: ptr("3"),
+	}), "deprecated, 100%")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = summary.NewSummary().AddAlert(summary.SummaryAlertNote, "All good").Write(summary.SummaryWriteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.AssertExportedVar("GREETING", "hello\nworld")
+	h.AssertMasked("hunter2")
+	h.AssertOutput("time", "noon")
+	h.AssertPath("/opt/tool/bin")
+	h.AssertAnnotation(actionstest.AnnotationWarning, "deprecated, 100%")
+	h.AssertSummaryContains("> [!NOTE]")
+	h.AssertSummaryHTMLContains(`<div class="markdown-alert markdown-alert-note">`)
+
+	annotations := h.Annotations()
+	if len(annotations) != 1 || annotations[0].File != "main.go" || annotations[0].StartLine != "3" {
+		t.Errorf("unexpected annotations %+v", annotations)
+	}
+	if got := os.Getenv("GREETING"); got != "hello\nworld" {
+		t.Errorf("expected exported variable in the environment, got %q", got)
+	}
+}
+
+func TestHarnessFailures(t *testing.T) {
+	fake := &fakeT{TB: t}
+	h := actionstest.New(fake, actionstest.Options{})
+
+	h.AssertOutput("missing", "value")
+	h.AssertMasked("secret")
+	h.AssertAnnotation(actionstest.AnnotationError, "boom")
+	if fake.errors != 3 {
+		t.Errorf("expected 3 failed assertions, got %d", fake.errors)
+	}
+}
+
+type fakeT struct {
+	testing.TB
+	errors int
+}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors++
+}
+
+func TestSnapshot(t *testing.T) {
+	h := actionstest.New(t, actionstest.Options{})
+
+	code := h.Run(func() error {
+		err := core.SetSecret("s3cr3t-token")
+		if err != nil {
+			return err
+		}
+		err = core.ExportVariable("TOKEN_FILE", filepath.Join(h.Dir(), "token"))
+		if err != nil {
+			return err
+		}
+		err = core.ExportVariable("AUTH", "Bearer s3cr3t-token")
+		if err != nil {
+			return err
+		}
+		message, err := filecommand.PrepareKeyValueMessage("notes", "line 1\nline 2")
+		if err != nil {
+			return err
+		}
+		err = filecommand.IssueFileCommand("OUTPUT", message)
+		if err != nil {
+			return err
+		}
+		_, err = summary.NewSummary().AddRaw("<h1>Done</h1>", ptr(true)).Write(summary.SummaryWriteOptions{})
+		if err != nil {
+			return err
+		}
+		return errors.New("upload failed")
+	})
+	if code != core.ExitCodeFailure {
+		t.Errorf("expected exit code %d, got %d", core.ExitCodeFailure, code)
+	}
+
+	h.AssertSnapshot()
+}
+
+// A test package's own -update flag must not clash with actionstest.
+var _ = flag.Bool("update", false, "update this package's golden files")
+
+func TestAssertGoldenUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "update.golden")
+
+	t.Setenv(actionstest.UpdateEnvVar, "1")
+	actionstest.AssertGolden(t, path, "first\n")
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bytes) != "first\n" {
+		t.Errorf("expected %q, got %q", "first\n", string(bytes))
+	}
+
+	t.Setenv(actionstest.UpdateEnvVar, "")
+	actionstest.AssertGolden(t, path, "first\n")
+}
@@ -0,0 +1,203 @@
+package actionstest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	core "github.com/jcbhmr/go-toolkit/actionscore"
+)
+
+// UpdateEnvVar makes AssertGolden write golden files instead of comparing
+// against them when set to a true value, e.g. ACTIONSTEST_UPDATE=1 go test.
+// It is an environment variable rather than a flag so it can't clash with a
+// -update flag of the test package.
+const UpdateEnvVar = "ACTIONSTEST_UPDATE"
+
+func updateGolden() bool {
+	update, _ := strconv.ParseBool(os.Getenv(UpdateEnvVar))
+	return update
+}
+
+// Run calls fn the way an action's main would and records the exit code the
+// action would end with: ExitCodeFailure if fn returns an error, the way a
+// main that exits with core.ExitCodeFailure on errors would, ExitCodeSuccess
+// otherwise.
+func (h *Harness) Run(fn func() error) core.ExitCode {
+	h.t.Helper()
+	err := fn()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ran = true
+	if err != nil {
+		h.exitCode = core.ExitCodeFailure
+		h.runErr = err
+	} else {
+		h.exitCode = core.ExitCodeSuccess
+		h.runErr = nil
+	}
+	return h.exitCode
+}
+
+// Snapshot describes everything the action did in a stable text format that
+// is meant to be diffed. Masked values are replaced with ***, and the
+// sandbox directory and the system temp directory are replaced with
+// $SANDBOX and $TMPDIR so snapshots don't change from run to run.
+func (h *Harness) Snapshot() string {
+	h.t.Helper()
+	var b strings.Builder
+
+	h.mu.Lock()
+	ran, exitCode, runErr := h.ran, h.exitCode, h.runErr
+	h.mu.Unlock()
+	if ran {
+		fmt.Fprintf(&b, "exit code: %d\n", exitCode)
+		if runErr != nil {
+			fmt.Fprintf(&b, "error: %s\n", runErr)
+		}
+	}
+
+	b.WriteString("\n--- commands\n")
+	for _, line := range strings.Split(strings.ReplaceAll(h.Stdout(), "\r\n", "\n"), "\n") {
+		if line != "" {
+			b.WriteString(line + "\n")
+		}
+	}
+	writeKeyValues(&b, "outputs", h.Outputs())
+	writeKeyValues(&b, "env", h.ExportedVars())
+	writeKeyValues(&b, "state", h.State())
+	b.WriteString("\n--- path\n")
+	for _, path := range h.Paths() {
+		b.WriteString(path + "\n")
+	}
+	b.WriteString("\n--- summary\n")
+	b.WriteString(strings.ReplaceAll(h.Summary(), "\r\n", "\n"))
+
+	return h.normalize(b.String())
+}
+
+func writeKeyValues(b *strings.Builder, section string, values map[string]string) {
+	b.WriteString("\n--- " + section + "\n")
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := values[key]
+		if strings.Contains(value, "\n") {
+			fmt.Fprintf(b, "%s<<EOF\n%s\nEOF\n", key, value)
+		} else {
+			fmt.Fprintf(b, "%s=%s\n", key, value)
+		}
+	}
+}
+
+func (h *Harness) normalize(s string) string {
+	masks := h.Masks()
+	// Replace longer secrets first so a secret that contains another one is
+	// still fully masked.
+	sort.Slice(masks, func(i, j int) bool { return len(masks[i]) > len(masks[j]) })
+	for _, mask := range masks {
+		if strings.TrimSpace(mask) != "" {
+			s = strings.ReplaceAll(s, mask, "***")
+		}
+	}
+
+	type replacement struct{ old, new string }
+	var replacements []replacement
+	for _, dir := range []struct{ path, name string }{{h.dir, "$SANDBOX"}, {os.TempDir(), "$TMPDIR"}} {
+		replacements = append(replacements, replacement{dir.path, dir.name})
+		if resolved, err := filepath.EvalSymlinks(dir.path); err == nil && resolved != dir.path {
+			replacements = append(replacements, replacement{resolved, dir.name})
+		}
+		if slashed := filepath.ToSlash(dir.path); slashed != dir.path {
+			replacements = append(replacements, replacement{slashed, dir.name})
+		}
+	}
+	// $SANDBOX lives inside $TMPDIR, so the longest paths go first.
+	sort.SliceStable(replacements, func(i, j int) bool { return len(replacements[i].old) > len(replacements[j].old) })
+	for _, r := range replacements {
+		s = strings.ReplaceAll(s, r.old, r.new)
+	}
+	return s
+}
+
+var goldenNameRegExp = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// AssertSnapshot compares Snapshot with testdata/<test name>.golden. Run the
+// tests with ACTIONSTEST_UPDATE=1 to write the current snapshot to the golden
+// file instead.
+func (h *Harness) AssertSnapshot() {
+	h.t.Helper()
+	name := goldenNameRegExp.ReplaceAllString(h.t.Name(), "_")
+	AssertGolden(h.t, filepath.Join("testdata", name+".golden"), h.Snapshot())
+}
+
+// AssertGolden compares got with the contents of the golden file at path, or
+// writes got to it when the tests are run with ACTIONSTEST_UPDATE=1.
+func AssertGolden(t testing.TB, path string, got string) {
+	t.Helper()
+	if updateGolden() {
+		err := os.MkdirAll(filepath.Dir(path), 0777)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(got), 0666)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(fmt.Errorf("%w (run with ACTIONSTEST_UPDATE=1 to create it)", err))
+	}
+	expected := strings.ReplaceAll(string(bytes), "\r\n", "\n")
+	if got != expected {
+		t.Errorf("%s does not match (run with ACTIONSTEST_UPDATE=1 to accept the new output):\n%s", path, lineDiff(expected, got))
+	}
+}
+
+// lineDiff is a minimal diff for test failures: it lists lines that only
+// appear on one side, using the longest common subsequence of lines.
+func lineDiff(a string, b string) string {
+	x := strings.Split(a, "\n")
+	y := strings.Split(b, "\n")
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			out.WriteString("  " + x[i] + "\n")
+			i++
+			j++
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			out.WriteString("- " + x[i] + "\n")
+			i++
+		default:
+			out.WriteString("+ " + y[j] + "\n")
+			j++
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/expression"
+)
+
+// expressionContexts builds the contexts ${{ }} in input values are evaluated
+// against from the synthetic runner environment: github and runner from the
+// GITHUB_* and RUNNER_* variables, env from -env and inputs from the raw
+// -input values.
+func expressionContexts(env []string, options runOptions) (expression.Contexts, error) {
+	github := map[string]any{}
+	runner := map[string]any{}
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		switch {
+		case strings.HasPrefix(k, "GITHUB_"):
+			github[strings.ToLower(strings.TrimPrefix(k, "GITHUB_"))] = v
+		case strings.HasPrefix(k, "RUNNER_"):
+			runner[strings.ToLower(strings.TrimPrefix(k, "RUNNER_"))] = v
+		}
+	}
+	// github.token is GITHUB_TOKEN, if it was passed through. The file command
+	// paths aren't part of the github context.
+	if _, ok := github["token"]; !ok {
+		github["token"] = ""
+	}
+	delete(github, "env")
+	delete(github, "output")
+	delete(github, "path")
+	delete(github, "state")
+	delete(github, "step_summary")
+
+	var event any = map[string]any{}
+	if eventPath := getEnv(env, "GITHUB_EVENT_PATH"); eventPath != "" {
+		bytes, err := os.ReadFile(eventPath)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(bytes, &event)
+		if err != nil {
+			return nil, fmt.Errorf("event payload %s: %w", eventPath, err)
+		}
+	}
+	github["event"] = event
+
+	envContext := map[string]any{}
+	for _, kv := range options.env {
+		envContext[kv[0]] = kv[1]
+	}
+	inputs := map[string]any{}
+	for _, kv := range options.inputs {
+		inputs[kv[0]] = kv[1]
+	}
+	return expression.Contexts{
+		"github": github,
+		"runner": runner,
+		"env":    envContext,
+		"inputs": inputs,
+	}, nil
+}
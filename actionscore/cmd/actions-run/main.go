@@ -0,0 +1,79 @@
+// Command actions-run runs a Go action locally with a synthetic GitHub
+// Actions environment and reports what the action did.
+//
+//	actions-run [flags] <package or binary> [args...]
+//
+// The target is run directly if it is an executable file; otherwise it is
+// built with "go build". Inputs are passed with -input name=value (repeatable)
+// and show up as INPUT_* variables; ${{ }} expressions in their values are
+// evaluated first against the github, runner, env and inputs contexts.
+// Workflow commands printed by the action are echoed with masked values
+// hidden, and once it exits actions-run prints its outputs, exported
+// variables, PATH additions, saved state and job summary. With -post the
+// action is run a second time as its post step, with the state saved by the
+// main step available as STATE_* variables.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type keyValueFlag []string
+
+func (f *keyValueFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *keyValueFlag) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("expected name=value, got %q", value)
+	}
+	*f = append(*f, value)
+	return nil
+}
+
+func main() {
+	var options runOptions
+	var inputs, env keyValueFlag
+	flag.Var(&inputs, "input", "action input as `name=value` (repeatable)")
+	flag.Var(&env, "env", "extra environment variable as `NAME=value` (repeatable)")
+	flag.StringVar(&options.eventName, "event-name", "workflow_dispatch", "GITHUB_EVENT_NAME to report")
+	flag.StringVar(&options.eventPath, "event", "", "webhook event payload `file` (defaults to an empty object)")
+	flag.StringVar(&options.repository, "repo", "", "GITHUB_REPOSITORY as `owner/repo` (defaults to the origin remote)")
+	flag.StringVar(&options.workspace, "workspace", "", "GITHUB_WORKSPACE (defaults to the current directory)")
+	flag.StringVar(&options.summaryHTML, "summary-html", "", "also render the job summary to this HTML `file`")
+	flag.BoolVar(&options.post, "post", false, "run the post step after the main step, with its saved state")
+	flag.BoolVar(&options.keep, "keep", false, "keep the temporary runner directory")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: actions-run [flags] <package or binary> [args...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	options.target = flag.Arg(0)
+	options.args = flag.Args()[1:]
+	options.inputs = parseKeyValues(inputs)
+	options.env = parseKeyValues(env)
+
+	exitCode, err := run(options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "actions-run: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(exitCode)
+}
+
+func parseKeyValues(values []string) [][2]string {
+	var pairs [][2]string
+	for _, value := range values {
+		k, v, _ := strings.Cut(value, "=")
+		pairs = append(pairs, [2]string{k, v})
+	}
+	return pairs
+}
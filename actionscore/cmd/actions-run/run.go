@@ -0,0 +1,537 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/command"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/expression"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/filecommand"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/summary"
+)
+
+type runOptions struct {
+	target      string
+	args        []string
+	inputs      [][2]string
+	env         [][2]string
+	eventName   string
+	eventPath   string
+	repository  string
+	workspace   string
+	summaryHTML string
+	post        bool
+	keep        bool
+}
+
+// phaseResult is everything a step did, read back from its command stream
+// and file command files.
+type phaseResult struct {
+	exitCode int
+	outputs  map[string]string
+	env      map[string]string
+	state    map[string]string
+	paths    []string
+	masks    []string
+	summary  string
+}
+
+func run(options runOptions) (int, error) {
+	dir, err := os.MkdirTemp("", "actions-run-")
+	if err != nil {
+		return 0, err
+	}
+	if options.keep {
+		fmt.Fprintf(os.Stderr, "actions-run: keeping %s\n", dir)
+	} else {
+		defer os.RemoveAll(dir)
+	}
+
+	executable, err := resolveExecutable(options.target, dir)
+	if err != nil {
+		return 0, err
+	}
+	env, err := baseEnv(options, dir)
+	if err != nil {
+		return 0, err
+	}
+
+	mainResult, err := runPhase("main", executable, options.args, env, dir, nil)
+	if err != nil {
+		return 0, err
+	}
+	printResult("main", mainResult, options.summaryHTML)
+	if !options.post {
+		return mainResult.exitCode, nil
+	}
+
+	post, err := runPhase("post", executable, options.args, postEnv(env, mainResult), dir, mainResult.masks)
+	if err != nil {
+		return 0, err
+	}
+	printResult("post", post, postSummaryHTML(options.summaryHTML))
+	if mainResult.exitCode != 0 {
+		return mainResult.exitCode, nil
+	}
+	return post.exitCode, nil
+}
+
+// postEnv is the environment of the post step: the main step's environment
+// with the variables and PATH entries the main step exported, plus its saved
+// state as STATE_* variables.
+func postEnv(env []string, main *phaseResult) []string {
+	env = append([]string{}, env...)
+	for _, name := range sortedKeys(main.env) {
+		env = setEnv(env, name, main.env[name])
+	}
+	if len(main.paths) > 0 {
+		paths := append(reversed(main.paths), getEnv(env, "PATH"))
+		env = setEnv(env, "PATH", strings.Join(paths, string(os.PathListSeparator)))
+	}
+	for _, name := range sortedKeys(main.state) {
+		env = setEnv(env, "STATE_"+name, main.state[name])
+	}
+	return env
+}
+
+// postSummaryHTML renders the post step's summary next to the main step's,
+// e.g. summary.post.html for summary.html.
+func postSummaryHTML(summaryHTML string) string {
+	if summaryHTML == "" {
+		return ""
+	}
+	return strings.TrimSuffix(summaryHTML, filepath.Ext(summaryHTML)) + ".post" + filepath.Ext(summaryHTML)
+}
+
+// resolveExecutable returns target if it is an executable file, and
+// otherwise builds it as a Go package.
+func resolveExecutable(target string, dir string) (string, error) {
+	if stats, err := os.Stat(target); err == nil && stats.Mode().IsRegular() {
+		return filepath.Abs(target)
+	}
+
+	executable := filepath.Join(dir, "action")
+	if runtime.GOOS == "windows" {
+		executable += ".exe"
+	}
+	if stats, err := os.Stat(target); err == nil && stats.IsDir() && !strings.HasPrefix(target, ".") && !filepath.IsAbs(target) {
+		target = "./" + target
+	}
+	cmd := exec.Command("go", "build", "-o", executable, target)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("building %s: %w", target, err)
+	}
+	return executable, nil
+}
+
+func baseEnv(options runOptions, dir string) ([]string, error) {
+	workspace := options.workspace
+	if workspace == "" {
+		var err error
+		workspace, err = os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+	}
+	workspace, err := filepath.Abs(workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	eventPath := options.eventPath
+	if eventPath == "" {
+		eventPath = filepath.Join(dir, "event.json")
+		err := os.WriteFile(eventPath, []byte("{}\n"), 0666)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		eventPath, err = filepath.Abs(eventPath)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(eventPath); err != nil {
+			return nil, err
+		}
+	}
+
+	repository := options.repository
+	if repository == "" {
+		repository = gitRepository(workspace)
+	}
+	owner, _, _ := strings.Cut(repository, "/")
+	sha := git(workspace, "rev-parse", "HEAD")
+	if sha == "" {
+		sha = strings.Repeat("0", 40)
+	}
+	ref := git(workspace, "symbolic-ref", "-q", "HEAD")
+	if ref == "" {
+		ref = "refs/heads/main"
+	}
+	actor := "local"
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		actor = filepath.Base(u.Username)
+	}
+	runnerTemp := filepath.Join(dir, "runner_temp")
+	toolCache := filepath.Join(dir, "tool_cache")
+	for _, d := range []string{runnerTemp, toolCache} {
+		err := os.MkdirAll(d, 0777)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	env := os.Environ()
+	synthetic := [][2]string{
+		{"CI", "true"},
+		{"GITHUB_ACTIONS", "true"},
+		{"GITHUB_ACTION", "__run"},
+		{"GITHUB_ACTOR", actor},
+		{"GITHUB_TRIGGERING_ACTOR", actor},
+		{"GITHUB_EVENT_NAME", options.eventName},
+		{"GITHUB_EVENT_PATH", eventPath},
+		{"GITHUB_REPOSITORY", repository},
+		{"GITHUB_REPOSITORY_OWNER", owner},
+		{"GITHUB_SHA", sha},
+		{"GITHUB_REF", ref},
+		{"GITHUB_REF_NAME", refName(ref)},
+		{"GITHUB_REF_TYPE", "branch"},
+		{"GITHUB_WORKFLOW", "actions-run"},
+		{"GITHUB_WORKFLOW_REF", repository + "/.github/workflows/actions-run.yml@" + ref},
+		{"GITHUB_JOB", "local"},
+		{"GITHUB_RUN_ID", "1"},
+		{"GITHUB_RUN_NUMBER", "1"},
+		{"GITHUB_RUN_ATTEMPT", "1"},
+		{"GITHUB_WORKSPACE", workspace},
+		{"GITHUB_SERVER_URL", "https://github.com"},
+		{"GITHUB_API_URL", "https://api.github.com"},
+		{"GITHUB_GRAPHQL_URL", "https://api.github.com/graphql"},
+		{"RUNNER_NAME", "actions-run"},
+		{"RUNNER_OS", runnerOS()},
+		{"RUNNER_ARCH", runnerArch()},
+		{"RUNNER_ENVIRONMENT", "self-hosted"},
+		{"RUNNER_TEMP", runnerTemp},
+		{"RUNNER_TOOL_CACHE", toolCache},
+	}
+	for _, kv := range synthetic {
+		env = setEnv(env, kv[0], kv[1])
+	}
+	for _, kv := range options.env {
+		env = setEnv(env, kv[0], kv[1])
+	}
+	contexts, err := expressionContexts(env, options)
+	if err != nil {
+		return nil, err
+	}
+	for _, kv := range options.inputs {
+		value, err := expression.EvaluateTemplate(kv[1], contexts, expression.Options{})
+		if err != nil {
+			return nil, fmt.Errorf("input %s: %w", kv[0], err)
+		}
+		env = setEnv(env, "INPUT_"+strings.ToUpper(strings.ReplaceAll(kv[0], " ", "_")), value)
+	}
+	return env, nil
+}
+
+func git(dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// refName is the short name of ref, which for a branch like
+// refs/heads/feature/x keeps everything after refs/heads/.
+func refName(ref string) string {
+	for _, prefix := range []string{"refs/heads/", "refs/tags/"} {
+		if name, ok := strings.CutPrefix(ref, prefix); ok {
+			return name
+		}
+	}
+	return ref
+}
+
+func gitRepository(dir string) string {
+	remote := strings.TrimSuffix(git(dir, "remote", "get-url", "origin"), ".git")
+	if i := strings.LastIndexAny(remote, ":/"); i >= 0 {
+		if j := strings.LastIndexAny(remote[:i], ":/"); j >= 0 {
+			return remote[j+1:]
+		}
+	}
+	return "local/" + filepath.Base(dir)
+}
+
+func runnerOS() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "Windows"
+	case "darwin":
+		return "macOS"
+	default:
+		return "Linux"
+	}
+}
+
+func runnerArch() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "X64"
+	case "386":
+		return "X86"
+	case "arm64":
+		return "ARM64"
+	case "arm":
+		return "ARM"
+	default:
+		return strings.ToUpper(runtime.GOARCH)
+	}
+}
+
+func runPhase(phase string, executable string, args []string, env []string, dir string, masks []string) (*phaseResult, error) {
+	phaseDir := filepath.Join(dir, phase)
+	err := os.MkdirAll(phaseDir, 0777)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]string{}
+	for _, name := range []string{"GITHUB_ENV", "GITHUB_OUTPUT", "GITHUB_STATE", "GITHUB_PATH", summary.SummaryEnvVar} {
+		files[name] = filepath.Join(phaseDir, strings.ToLower(name))
+		err := os.WriteFile(files[name], nil, 0666)
+		if err != nil {
+			return nil, err
+		}
+		env = setEnv(env, name, files[name])
+	}
+
+	fmt.Fprintf(os.Stderr, "==> Running %s step\n", phase)
+	cmd := exec.Command(executable, args...)
+	cmd.Env = env
+	cmd.Dir = getEnv(env, "GITHUB_WORKSPACE")
+	cmd.Stdin = os.Stdin
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &phaseResult{
+		outputs: map[string]string{},
+		env:     map[string]string{},
+		state:   map[string]string{},
+		masks:   append([]string{}, masks...),
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	// Both streams go through the same masking as the runner's log, and
+	// workflow commands can show up on either.
+	scan := func(r io.Reader, w io.Writer) {
+		defer wg.Done()
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			mu.Lock()
+			if c, ok := command.Parse(line); ok {
+				result.apply(c)
+			}
+			fmt.Fprintln(w, mask(line, result.masks))
+			mu.Unlock()
+		}
+		if err := scanner.Err(); err != nil {
+			// Keep draining the pipe so the step doesn't block writing to
+			// it. The rest can't be masked line by line, so it's dropped
+			// rather than shown.
+			fmt.Fprintf(os.Stderr, "==> Discarding the rest of the %s step's output: %v\n", phase, err)
+			io.Copy(io.Discard, r)
+		}
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+	wg.Add(2)
+	go scan(stdout, os.Stdout)
+	go scan(stderr, os.Stderr)
+	wg.Wait()
+	err = cmd.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		result.exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = result.readFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// readFiles records what the step wrote to its file command files, keyed by
+// the variable that points to them.
+func (r *phaseResult) readFiles(files map[string]string) error {
+	for name, target := range map[string]map[string]string{
+		"GITHUB_ENV":    r.env,
+		"GITHUB_OUTPUT": r.outputs,
+		"GITHUB_STATE":  r.state,
+	} {
+		content, err := os.ReadFile(files[name])
+		if err != nil {
+			return err
+		}
+		messages, err := filecommand.ParseKeyValueMessages(string(content))
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		for _, message := range messages {
+			target[message.Key] = message.Value
+		}
+	}
+	content, err := os.ReadFile(files["GITHUB_PATH"])
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n") {
+		if line != "" {
+			r.paths = append(r.paths, line)
+		}
+	}
+	content, err = os.ReadFile(files[summary.SummaryEnvVar])
+	if err != nil {
+		return err
+	}
+	r.summary = string(content)
+	return nil
+}
+
+// apply records the effect of the commands the runner still accepts on
+// stdout.
+func (r *phaseResult) apply(c command.Command) {
+	switch c.Command {
+	case "set-output":
+		r.outputs[c.Properties["name"]] = c.Message
+	case "set-env":
+		r.env[c.Properties["name"]] = c.Message
+	case "save-state":
+		r.state[c.Properties["name"]] = c.Message
+	case "add-path":
+		r.paths = append(r.paths, c.Message)
+	case "add-mask":
+		if strings.TrimSpace(c.Message) != "" {
+			r.masks = append(r.masks, c.Message)
+		}
+	}
+}
+
+func mask(s string, masks []string) string {
+	for _, m := range masks {
+		s = strings.ReplaceAll(s, m, "***")
+	}
+	return s
+}
+
+func printResult(phase string, result *phaseResult, summaryHTML string) {
+	w := os.Stderr
+	fmt.Fprintf(w, "==> %s step exited with code %d\n", phase, result.exitCode)
+	printMap(w, "Outputs", result.outputs, result.masks)
+	printMap(w, "Exported variables", result.env, result.masks)
+	printMap(w, "Saved state", result.state, result.masks)
+	if len(result.paths) > 0 {
+		fmt.Fprintln(w, "--- PATH additions")
+		for _, p := range result.paths {
+			fmt.Fprintln(w, mask(p, result.masks))
+		}
+	}
+	if result.summary != "" {
+		fmt.Fprintln(w, "--- Job summary")
+		fmt.Fprint(w, mask(result.summary, result.masks))
+		if !strings.HasSuffix(result.summary, "\n") {
+			fmt.Fprintln(w)
+		}
+		if summaryHTML != "" {
+			title := "Job summary (" + phase + ")"
+			rendered := summary.RenderHTML(mask(result.summary, result.masks), summary.SummaryRenderOptions{Title: &title})
+			err := os.WriteFile(summaryHTML, []byte(rendered), 0666)
+			if err != nil {
+				fmt.Fprintf(w, "actions-run: %v\n", err)
+			} else {
+				fmt.Fprintf(w, "--- Rendered job summary to %s\n", summaryHTML)
+			}
+		}
+	}
+}
+
+func printMap(w io.Writer, title string, values map[string]string, masks []string) {
+	if len(values) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "--- %s\n", title)
+	for _, key := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s=%s\n", key, mask(values[key], masks))
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func reversed(s []string) []string {
+	r := make([]string, len(s))
+	for i, v := range s {
+		r[len(s)-1-i] = v
+	}
+	return r
+}
+
+func envKey(key string) string {
+	if runtime.GOOS == "windows" {
+		return strings.ToUpper(key)
+	}
+	return key
+}
+
+func getEnv(env []string, key string) string {
+	for i := len(env) - 1; i >= 0; i-- {
+		k, v, _ := strings.Cut(env[i], "=")
+		if envKey(k) == envKey(key) {
+			return v
+		}
+	}
+	return ""
+}
+
+func setEnv(env []string, key string, value string) []string {
+	out := env[:0:0]
+	for _, kv := range env {
+		k, _, _ := strings.Cut(kv, "=")
+		if envKey(k) != envKey(key) {
+			out = append(out, kv)
+		}
+	}
+	return append(out, key+"="+value)
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/command"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/summary"
+)
+
+// TestMain lets the test binary stand in for an action: run with
+// ACTIONS_RUN_TEST_ACTION set, it behaves as testAction instead of running
+// the tests.
+func TestMain(m *testing.M) {
+	if os.Getenv("ACTIONS_RUN_TEST_ACTION") != "" {
+		os.Exit(testAction())
+	}
+	os.Exit(m.Run())
+}
+
+// testAction saves state and exports a variable and PATH entry in its main
+// step, and checks it gets them back in its post step. With
+// ACTIONS_RUN_TEST_ACTION=long it instead prints a line too long to scan.
+func testAction() int {
+	if os.Getenv("ACTIONS_RUN_TEST_ACTION") == "long" {
+		fmt.Println(strings.Repeat("x", 17*1024*1024))
+		fmt.Println("after")
+		return 0
+	}
+	appendFile := func(name string, content string) {
+		f, err := os.OpenFile(os.Getenv(name), os.O_APPEND|os.O_WRONLY, 0666)
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		fmt.Fprint(f, content)
+	}
+	if os.Getenv("STATE_phase") == "" {
+		fmt.Println("::add-mask::s3cr3t")
+		fmt.Println("the secret is s3cr3t")
+		appendFile("GITHUB_STATE", "phase=main\n")
+		appendFile("GITHUB_ENV", "FROM_MAIN<<EOF\nyes\nEOF\n")
+		appendFile("GITHUB_PATH", "/opt/tool/bin\n")
+		appendFile("GITHUB_OUTPUT", "greeting="+os.Getenv("INPUT_WHO")+"\n")
+		appendFile(summary.SummaryEnvVar, "# Report for s3cr3t\n")
+		return 0
+	}
+	if os.Getenv("STATE_phase") != "main" || os.Getenv("FROM_MAIN") != "yes" || !strings.HasPrefix(os.Getenv("PATH"), "/opt/tool/bin"+string(os.PathListSeparator)) {
+		return 3
+	}
+	return 0
+}
+
+func TestRun(t *testing.T) {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	summaryHTML := filepath.Join(t.TempDir(), "summary.html")
+	exitCode, err := run(runOptions{
+		target:      executable,
+		inputs:      [][2]string{{"who", "${{ github.repository }}"}},
+		env:         [][2]string{{"ACTIONS_RUN_TEST_ACTION", "1"}},
+		eventName:   "push",
+		repository:  "octo/repo",
+		workspace:   t.TempDir(),
+		summaryHTML: summaryHTML,
+		post:        true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != 0 {
+		t.Errorf("expected the post step to see the main step's state, got exit code %d", exitCode)
+	}
+	bytes, err := os.ReadFile(summaryHTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if html := string(bytes); strings.Contains(html, "s3cr3t") || !strings.Contains(html, "Report for") {
+		t.Errorf("expected the rendered summary to be masked, got %q", html)
+	}
+}
+
+func TestRunPhaseLongLine(t *testing.T) {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := append(os.Environ(), "ACTIONS_RUN_TEST_ACTION=long", "GITHUB_WORKSPACE="+t.TempDir())
+	done := make(chan error, 1)
+	go func() {
+		_, err := runPhase("main", executable, nil, env, t.TempDir(), nil)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Minute):
+		t.Fatal("expected the step to exit after printing a line longer than the scanner buffer")
+	}
+}
+
+func TestBaseEnv(t *testing.T) {
+	dir := t.TempDir()
+	workspace := t.TempDir()
+	env, err := baseEnv(runOptions{
+		inputs:     [][2]string{{"greeting", "hi ${{ env.NAME }} from ${{ github.event_name }}"}, {"dry run", "true"}},
+		env:        [][2]string{{"NAME", "octocat"}},
+		eventName:  "workflow_dispatch",
+		repository: "octo/repo",
+		workspace:  workspace,
+	}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, expected := range map[string]string{
+		"GITHUB_ACTIONS":          "true",
+		"GITHUB_EVENT_NAME":       "workflow_dispatch",
+		"GITHUB_EVENT_PATH":       filepath.Join(dir, "event.json"),
+		"GITHUB_REPOSITORY":       "octo/repo",
+		"GITHUB_REPOSITORY_OWNER": "octo",
+		"GITHUB_WORKSPACE":        workspace,
+		"GITHUB_REF":              "refs/heads/main",
+		"RUNNER_TEMP":             filepath.Join(dir, "runner_temp"),
+		"NAME":                    "octocat",
+		"INPUT_GREETING":          "hi octocat from workflow_dispatch",
+		"INPUT_DRY_RUN":           "true",
+	} {
+		if got := getEnv(env, key); got != expected {
+			t.Errorf("expected %s=%q, got %q", key, expected, got)
+		}
+	}
+	_, err = baseEnv(runOptions{inputs: [][2]string{{"x", "${{ nope( }}"}}, workspace: workspace}, t.TempDir())
+	if err == nil {
+		t.Error("expected an error for an invalid input expression")
+	}
+}
+
+func TestRefName(t *testing.T) {
+	for ref, expected := range map[string]string{
+		"refs/heads/main":      "main",
+		"refs/heads/feature/x": "feature/x",
+		"refs/tags/v1.2.3":     "v1.2.3",
+		"refs/tags/release/v1": "release/v1",
+		"refs/pull/1/merge":    "refs/pull/1/merge",
+	} {
+		if got := refName(ref); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+}
+
+func TestBaseEnvBranchWithSlash(t *testing.T) {
+	workspace := t.TempDir()
+	git(workspace, "init", "-q")
+	git(workspace, "symbolic-ref", "HEAD", "refs/heads/feature/x")
+	if git(workspace, "symbolic-ref", "-q", "HEAD") != "refs/heads/feature/x" {
+		t.Skip("git is not available")
+	}
+	env, err := baseEnv(runOptions{workspace: workspace}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := getEnv(env, "GITHUB_REF_NAME"); got != "feature/x" {
+		t.Errorf("expected %q, got %q", "feature/x", got)
+	}
+}
+
+func TestPhaseResult(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{}
+	for name, content := range map[string]string{
+		"GITHUB_ENV":          "A=1\nMULTI<<EOF\nx\ny\nEOF\n",
+		"GITHUB_OUTPUT":       "out=v\n",
+		"GITHUB_STATE":        "pid=42\n",
+		"GITHUB_PATH":         "/a\r\n/b\n",
+		summary.SummaryEnvVar: "# hi\n",
+	} {
+		files[name] = filepath.Join(dir, name)
+		err := os.WriteFile(files[name], []byte(content), 0666)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result := &phaseResult{outputs: map[string]string{}, env: map[string]string{}, state: map[string]string{}}
+	for _, line := range []string{
+		"::set-output name=legacy::old",
+		"::save-state name=legacy::s",
+		"::add-path::/legacy",
+		"::add-mask::hunter2",
+		"::add-mask::  ",
+	} {
+		c, ok := command.Parse(line)
+		if !ok {
+			t.Fatalf("expected %q to parse", line)
+		}
+		result.apply(c)
+	}
+	err := result.readFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &phaseResult{
+		outputs: map[string]string{"legacy": "old", "out": "v"},
+		env:     map[string]string{"A": "1", "MULTI": "x\ny"},
+		state:   map[string]string{"legacy": "s", "pid": "42"},
+		paths:   []string{"/legacy", "/a", "/b"},
+		masks:   []string{"hunter2"},
+		summary: "# hi\n",
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %+v, got %+v", expected, result)
+	}
+	if got := mask("hunter2 and hunter2", result.masks); got != "*** and ***" {
+		t.Errorf("expected masked line, got %q", got)
+	}
+}
+
+func TestPostEnv(t *testing.T) {
+	sep := string(os.PathListSeparator)
+	env := []string{"PATH=/usr/bin", "KEEP=1", "OVERRIDE=old"}
+	main := &phaseResult{
+		env:   map[string]string{"OVERRIDE": "new"},
+		state: map[string]string{"pid": "42"},
+		paths: []string{"/first", "/second"},
+	}
+	got := postEnv(env, main)
+	for key, expected := range map[string]string{
+		"PATH":       "/second" + sep + "/first" + sep + "/usr/bin",
+		"KEEP":       "1",
+		"OVERRIDE":   "new",
+		"STATE_pid":  "42",
+		"STATE_none": "",
+	} {
+		if v := getEnv(got, key); v != expected {
+			t.Errorf("expected %s=%q, got %q", key, expected, v)
+		}
+	}
+	if getEnv(env, "OVERRIDE") != "old" {
+		t.Error("expected the main step's environment to be left alone")
+	}
+
+	if got := postSummaryHTML("out/summary.html"); got != "out/summary.post.html" {
+		t.Errorf("expected %q, got %q", "out/summary.post.html", got)
+	}
+	if got := postSummaryHTML(""); got != "" {
+		t.Errorf("expected no post summary file, got %q", got)
+	}
+}
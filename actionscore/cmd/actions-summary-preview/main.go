@@ -0,0 +1,61 @@
+// Command actions-summary-preview renders a job summary to a standalone HTML
+// file so summary layouts can be checked locally without pushing to CI.
+//
+//	actions-summary-preview [-o summary.html] [-title title] [file]
+//
+// The summary is read from file, or from $GITHUB_STEP_SUMMARY when no file is
+// given. Use "-" to read from stdin. The HTML is written to stdout unless -o
+// is set.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/summary"
+)
+
+func main() {
+	output := flag.String("o", "", "write the HTML to this file instead of stdout")
+	title := flag.String("title", "Job summary", "title of the HTML page")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: actions-summary-preview [-o summary.html] [-title title] [file]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	err := run(flag.Arg(0), *output, *title)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "actions-summary-preview: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(input string, output string, title string) error {
+	if input == "" {
+		input = os.Getenv(summary.SummaryEnvVar)
+		if input == "" {
+			return fmt.Errorf("no file given and %s is not set", summary.SummaryEnvVar)
+		}
+	}
+
+	var content []byte
+	var err error
+	if input == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(input)
+	}
+	if err != nil {
+		return err
+	}
+
+	rendered := summary.RenderHTML(string(content), summary.SummaryRenderOptions{Title: &title})
+	if output == "" {
+		_, err = io.WriteString(os.Stdout, rendered)
+		return err
+	}
+	return os.WriteFile(output, []byte(rendered), 0666)
+}
@@ -1,5 +1,14 @@
 package core
 
+import (
+	"context"
+	"os"
+
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/oidcutils"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/pathutils"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/platform"
+)
+
 type InputOptions struct {
 	Required       *bool
 	TrimWhitespace *bool
@@ -27,4 +36,127 @@ func ExportVariable(name string, val any) error {
 
 func SetSecret(secret string) error {
 	return setSecret(secret)
-}
\ No newline at end of file
+}
+
+// GetIDToken requests a GitHub OIDC ID token for audience, or the default
+// audience if audience is nil. The job needs the id-token: write permission.
+func GetIDToken(ctx context.Context, audience *string) (string, error) {
+	return getIDToken(ctx, audience)
+}
+
+// The issuer and JWKS URL of GitHub.com ID tokens.
+const (
+	IDTokenIssuer  = oidcutils.Issuer
+	IDTokenJWKSURL = oidcutils.JWKSURL
+)
+
+type IDTokenClaims = oidcutils.Claims
+type JWKS = oidcutils.JWKS
+type VerifyIDTokenOptions = oidcutils.VerifyOptions
+
+// ParseIDTokenClaims decodes the claims of an ID token without verifying
+// its signature.
+func ParseIDTokenClaims(token string) (IDTokenClaims, error) {
+	return oidcutils.ParseClaims(token)
+}
+
+// VerifyIDToken checks the signature of an ID token against jwks and
+// validates its timestamps, issuer and audience.
+func VerifyIDToken(token string, jwks JWKS, options VerifyIDTokenOptions) (IDTokenClaims, error) {
+	return oidcutils.Verify(token, jwks, options)
+}
+
+func ParseJWKS(data []byte) (JWKS, error) {
+	return oidcutils.ParseJWKS(data)
+}
+
+func FetchJWKS(ctx context.Context, url string) (JWKS, error) {
+	return oidcutils.FetchJWKS(ctx, url)
+}
+
+// Token is an ID token. It has the shape of golang.org/x/oauth2.Token.
+type Token = oidcutils.Token
+
+// TokenSource has the shape of golang.org/x/oauth2.TokenSource.
+type TokenSource interface {
+	Token() (*Token, error)
+}
+
+var idTokenCache = &oidcutils.TokenCache{Fetch: getIDToken}
+
+// IDTokenSource returns a TokenSource of ID tokens for audience, or the
+// default audience if nil. Tokens are cached per audience across all sources
+// and refreshed shortly before they expire; sources are safe for concurrent
+// use.
+func IDTokenSource(ctx context.Context, audience *string) TokenSource {
+	if audience != nil {
+		a := *audience
+		audience = &a
+	}
+	return idTokenSource{ctx: ctx, audience: audience}
+}
+
+type idTokenSource struct {
+	ctx      context.Context
+	audience *string
+}
+
+func (s idTokenSource) Token() (*Token, error) {
+	return idTokenCache.Token(s.ctx, s.audience)
+}
+
+type PlatformDetails = platform.Details
+type PlatformDetector = platform.Detector
+type Runner = platform.Runner
+
+// GetPlatformDetails returns the name, version and architecture of the OS
+// the action runs on. It is memoized.
+func GetPlatformDetails() (PlatformDetails, error) {
+	return platform.GetDetails()
+}
+
+// GetRunner returns the facts about the current runner, such as its tool
+// cache, CPUs, memory and free disk space.
+func GetRunner() Runner {
+	return platform.GetRunner()
+}
+
+// ToPosixPath converts the separators in p to forward slashes.
+func ToPosixPath(p string) string {
+	return toPosixPath(p)
+}
+
+// ToWin32Path converts the separators in p to backslashes.
+func ToWin32Path(p string) string {
+	return toWin32Path(p)
+}
+
+// ToPlatformPath converts the separators in p to those of the runner's OS.
+func ToPlatformPath(p string) string {
+	return toPlatformPath(p)
+}
+
+// ContainerWorkspace is where the runner mounts GITHUB_WORKSPACE inside
+// Docker container actions.
+const ContainerWorkspace = pathutils.ContainerWorkspace
+
+// RelativeToWorkspace returns p relative to GITHUB_WORKSPACE with forward
+// slashes, the form AnnotationProperties.File expects. ok is false if p is
+// outside the workspace or GITHUB_WORKSPACE is unset.
+func RelativeToWorkspace(p string) (rel string, ok bool) {
+	return pathutils.RelativeToWorkspace(hostGOOS(), os.Getenv("GITHUB_WORKSPACE"), p)
+}
+
+// ToContainerPath translates a path under GITHUB_WORKSPACE to where it
+// appears inside a Docker container action. ok is false if p is outside the
+// workspace or GITHUB_WORKSPACE is unset.
+func ToContainerPath(p string) (string, bool) {
+	return pathutils.ToContainerPath(hostGOOS(), os.Getenv("GITHUB_WORKSPACE"), p)
+}
+
+// FromContainerPath translates a path inside a Docker container action back
+// to the path under GITHUB_WORKSPACE on the runner. ok is false if p is
+// outside ContainerWorkspace or GITHUB_WORKSPACE is unset.
+func FromContainerPath(p string) (string, bool) {
+	return pathutils.FromContainerPath(hostGOOS(), os.Getenv("GITHUB_WORKSPACE"), p)
+}
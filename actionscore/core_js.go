@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"sync"
 	"syscall/js"
 
@@ -322,7 +323,8 @@ func getState(name string) string {
 	return v.String()
 }
 
-func getIDToken(aud *string) (string, error) {
+// The context is not used; the promise can't be cancelled.
+func getIDToken(ctx context.Context, aud *string) (string, error) {
 	var p js.Value
 	if aud == nil {
 		p = core.Get("getIDToken").Invoke()
@@ -363,6 +365,16 @@ func toPlatformPath(p string) string {
 	return v.String()
 }
 
+// hostGOOS is the GOOS of the machine Node.js runs on, since runtime.GOOS is
+// always "js".
+func hostGOOS() string {
+	platform := js.Global().Get("process").Get("platform").String()
+	if platform == "win32" {
+		return "windows"
+	}
+	return platform
+}
+
 type summaryType struct{ value js.Value }
 
 var summary = summaryType{core.Get("summary")}
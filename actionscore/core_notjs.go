@@ -2,13 +2,59 @@
 
 package core
 
-import "github.com/jcbhmr/go-toolkit/actionscore/internal/command"
+import (
+	"context"
+	"os"
+	"runtime"
+
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/command"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/filecommand"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/oidcutils"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/pathutils"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/utils"
+)
 
 func exportVariable(name string, val any) error {
-	panic("not implemented")
+	convertedVal, err := utils.ToCommandValue(val)
+	if err != nil {
+		return err
+	}
+	err = os.Setenv(name, convertedVal)
+	if err != nil {
+		return err
+	}
+
+	if os.Getenv("GITHUB_ENV") != "" {
+		message, err := filecommand.PrepareKeyValueMessage(name, val)
+		if err != nil {
+			return err
+		}
+		return filecommand.IssueFileCommand("ENV", message)
+	}
+
+	return command.IssueCommand("set-env", command.CommandProperties{"name": name}, convertedVal)
 }
 
 func setSecret(secret string) error {
 	return command.IssueCommand("add-mask", command.CommandProperties{}, secret)
 }
 
+func getIDToken(ctx context.Context, aud *string) (string, error) {
+	return oidcutils.GetIDToken(ctx, aud)
+}
+
+func toPosixPath(p string) string {
+	return pathutils.ToPosixPath(p)
+}
+
+func toWin32Path(p string) string {
+	return pathutils.ToWin32Path(p)
+}
+
+func toPlatformPath(p string) string {
+	return pathutils.ToPlatformPath(p)
+}
+
+func hostGOOS() string {
+	return runtime.GOOS
+}
@@ -2,8 +2,11 @@ package command
 
 import (
 	"fmt"
+	"io"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/jcbhmr/go-toolkit/actionscore/internal/utils"
 )
@@ -18,14 +21,40 @@ var eol = func() string {
 
 type CommandProperties = map[string]any
 
+var (
+	outputMu sync.Mutex
+	output   io.Writer
+)
+
+// SetOutput redirects commands to w instead of os.Stdout until the returned
+// function is called. It exists so tests can capture commands without
+// swapping out os.Stdout.
+func SetOutput(w io.Writer) (restore func()) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	previous := output
+	output = w
+	return func() {
+		outputMu.Lock()
+		defer outputMu.Unlock()
+		output = previous
+	}
+}
+
 func IssueCommand(command string, properties CommandProperties, message any) error {
 	cmd := newCommand(command, properties, message)
 	cmdStr, err := cmd.string2()
 	if err != nil {
 		return err
 	}
-	fmt.Print(cmdStr + eol)
-	return nil
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	if output != nil {
+		_, err = io.WriteString(output, cmdStr+eol)
+	} else {
+		_, err = fmt.Print(cmdStr + eol)
+	}
+	return err
 }
 
 func Issue(name string, messageRaw *string) error {
@@ -58,30 +87,37 @@ func newCommand(commandVar string, properties CommandProperties, message any) *c
 func (c *command) string2() (string, error) {
 	cmdStr := cmdString + c.command
 	if len(c.properties) > 0 {
-		cmdStr += " "
+		keys := make([]string, 0, len(c.properties))
+		for k := range c.properties {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
 		first := true
-		for k, v := range c.properties {
-			if v != nil {
-				if first {
-					first = false
-				} else {
-					cmdStr += ","
-				}
-				ev, err := escapeProperty(v)
-				if err != nil {
-					return "", err
-				}
-				cmdStr += k + "=" + ev
+		for _, k := range keys {
+			ev, err := escapeProperty(c.properties[k])
+			if err != nil {
+				return "", err
 			}
+			// Like the JS toolkit, leave out unset properties (including nil
+			// pointers from utils.ToCommandProperties).
+			if ev == "" {
+				continue
+			}
+			if first {
+				cmdStr += " "
+				first = false
+			} else {
+				cmdStr += ","
+			}
+			cmdStr += k + "=" + ev
 		}
-		emessage, err := escapeData(c.message)
-		if err != nil {
-			return "", err
-		}
-		cmdStr += cmdString + emessage
-		return cmdStr, nil
 	}
-	return "", nil
+	emessage, err := escapeData(c.message)
+	if err != nil {
+		return "", err
+	}
+	cmdStr += cmdString + emessage
+	return cmdStr, nil
 }
 
 func escapeData(s any) (string, error) {
@@ -99,3 +135,52 @@ func escapeProperty(s any) (string, error) {
 	}
 	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(str), nil
 }
+
+// Command is a workflow command as read back from a log line, with its
+// properties and message unescaped.
+type Command struct {
+	Command    string
+	Properties map[string]string
+	Message    string
+}
+
+// Parse parses a single "::name key=value,...::message" line. It reports
+// false for lines that aren't workflow commands.
+func Parse(line string) (Command, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	if !strings.HasPrefix(line, cmdString) {
+		return Command{}, false
+	}
+	rest := line[len(cmdString):]
+	end := strings.Index(rest, cmdString)
+	if end < 0 {
+		return Command{}, false
+	}
+	head, message := rest[:end], rest[end+len(cmdString):]
+	name, properties, _ := strings.Cut(head, " ")
+	if name == "" {
+		return Command{}, false
+	}
+
+	cmd := Command{
+		Command:    name,
+		Properties: map[string]string{},
+		Message:    unescapeData(message),
+	}
+	for _, property := range strings.Split(properties, ",") {
+		if property == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(property, "=")
+		cmd.Properties[strings.TrimSpace(k)] = unescapeProperty(v)
+	}
+	return cmd, true
+}
+
+func unescapeData(s string) string {
+	return strings.NewReplacer("%0D", "\r", "%0A", "\n", "%25", "%").Replace(s)
+}
+
+func unescapeProperty(s string) string {
+	return strings.NewReplacer("%0D", "\r", "%0A", "\n", "%3A", ":", "%2C", ",", "%25", "%").Replace(s)
+}
@@ -4,6 +4,7 @@ import (
 	"io"
 	"os"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/jcbhmr/go-toolkit/actionscore/internal/command"
@@ -108,3 +109,74 @@ func TestCommandEscapesProperty(t *testing.T) {
 	command.IssueCommand("some-command", command.CommandProperties{}, "%25 %25 %0D %0D %0A %0A %3A %3A %2C %2C")
 	assertStdout(t, "::some-command::%2525 %2525 %250D %250D %250A %250A %253A %253A %252C %252C"+eol)
 }
+
+func TestCommandWithoutProperties(t *testing.T) {
+	beforeEach(t)
+	t.Cleanup(afterEach)
+
+	command.IssueCommand("add-mask", command.CommandProperties{}, "hunter2")
+	assertStdout(t, "::add-mask::hunter2"+eol)
+
+	resetFakeStdout(t)
+
+	command.IssueCommand("add-mask", nil, "hunter2")
+	assertStdout(t, "::add-mask::hunter2"+eol)
+}
+
+func TestCommandSortsProperties(t *testing.T) {
+	beforeEach(t)
+	t.Cleanup(afterEach)
+
+	for range 10 {
+		resetFakeStdout(t)
+		command.IssueCommand("warning", command.CommandProperties{
+			"title": "t",
+			"file":  "main.go",
+			"line":  "3",
+			"col":   "5",
+		}, "message")
+		assertStdout(t, "::warning col=5,file=main.go,line=3,title=t::message"+eol)
+	}
+}
+
+func TestCommandOmitsEmptyProperties(t *testing.T) {
+	beforeEach(t)
+	t.Cleanup(afterEach)
+
+	var unset *string
+	file := "main.go"
+	command.IssueCommand("warning", command.CommandProperties{
+		"title": unset,
+		"file":  &file,
+		"line":  "",
+		"col":   nil,
+	}, "message")
+	assertStdout(t, "::warning file=main.go::message"+eol)
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	var out strings.Builder
+	restore := command.SetOutput(&out)
+	defer restore()
+
+	message := "percent % cr \r lf \n colon : comma ,"
+	command.IssueCommand("some-command", command.CommandProperties{"name": "a:b,c%d", "empty": ""}, message)
+
+	cmd, ok := command.Parse(out.String())
+	if !ok {
+		t.Fatalf("expected %q to parse", out.String())
+	}
+	if cmd.Command != "some-command" {
+		t.Errorf("expected %q, got %q", "some-command", cmd.Command)
+	}
+	if len(cmd.Properties) != 1 || cmd.Properties["name"] != "a:b,c%d" {
+		t.Errorf("unexpected properties %q", cmd.Properties)
+	}
+	if cmd.Message != message {
+		t.Errorf("expected %q, got %q", message, cmd.Message)
+	}
+
+	if _, ok := command.Parse("not a command"); ok {
+		t.Error("expected plain text not to parse")
+	}
+}
@@ -0,0 +1,510 @@
+// Package expression evaluates the GitHub Actions expression language, the
+// syntax inside ${{ }}. Values follow the JSON data model: nil, bool,
+// float64, string, []any and map[string]any.
+//
+// See https://docs.github.com/actions/learn-github-actions/expressions.
+package expression
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	nan    = math.NaN()
+	posInf = math.Inf(1)
+)
+
+// Contexts maps context names (github, env, inputs, runner, steps, ...) to
+// their values. Values may be any Go value that encodes to JSON; maps,
+// slices and structs are converted to the JSON data model.
+type Contexts map[string]any
+
+type Options struct {
+	// The job status seen by success(), failure() and cancelled(). Defaults
+	// to "success".
+	Status string
+	// The directory hashFiles resolves patterns against. Defaults to
+	// github.workspace, then GITHUB_WORKSPACE, then the current directory.
+	Workspace string
+}
+
+// Evaluate evaluates a single expression, without the ${{ }} delimiters.
+func Evaluate(expr string, contexts Contexts, options Options) (any, error) {
+	n, err := parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %w", strings.TrimSpace(expr), err)
+	}
+	e, err := newEvaluator(contexts, options)
+	if err != nil {
+		return nil, err
+	}
+	v, err := e.eval(n)
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %w", strings.TrimSpace(expr), err)
+	}
+	return unwrap(v), nil
+}
+
+// filteredArray is the result of an object filter (.* or [*]). Indexing into
+// it indexes into each of its elements instead.
+type filteredArray []any
+
+func unwrap(v any) any {
+	if v, ok := v.(filteredArray); ok {
+		return []any(v)
+	}
+	return v
+}
+
+// EvaluateTemplate replaces every ${{ }} in text with its value converted to
+// a string, the way the runner treats values in action.yml and workflow
+// files.
+func EvaluateTemplate(text string, contexts Contexts, options Options) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.Index(text, "${{")
+		if start < 0 {
+			b.WriteString(text)
+			return b.String(), nil
+		}
+		end := findClose(text, start+3)
+		if end < 0 {
+			return "", fmt.Errorf("unclosed expression in %q", text)
+		}
+		v, err := Evaluate(text[start+3:end], contexts, options)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(text[:start])
+		b.WriteString(ToString(v))
+		text = text[end+2:]
+	}
+}
+
+// findClose returns the index of the }} closing an expression that starts at
+// i, skipping over string literals.
+func findClose(text string, i int) int {
+	inString := false
+	for ; i < len(text); i++ {
+		switch {
+		case text[i] == '\'':
+			inString = !inString
+		case !inString && strings.HasPrefix(text[i:], "}}"):
+			return i
+		}
+	}
+	return -1
+}
+
+// Truthy reports whether v is truthy: everything except false, 0, -0, "",
+// null and NaN.
+func Truthy(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case string:
+		return v != ""
+	default:
+		return true
+	}
+}
+
+// ToString converts a value to a string the way expressions do when they are
+// interpolated: null is "", numbers use their shortest form and arrays and
+// objects become "Array" and "Object".
+func ToString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case float64:
+		return formatNumber(v)
+	case string:
+		return v
+	case []any:
+		return "Array"
+	case map[string]any:
+		return "Object"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func formatNumber(n float64) string {
+	switch {
+	case math.IsNaN(n):
+		return "NaN"
+	case math.IsInf(n, 1):
+		return "Infinity"
+	case math.IsInf(n, -1):
+		return "-Infinity"
+	case n == 0:
+		return "0"
+	}
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func toNumber(v any) float64 {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case float64:
+		return v
+	case string:
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return 0
+		}
+		n, err := parseNumber(s)
+		if err != nil {
+			return nan
+		}
+		return n
+	default:
+		return nan
+	}
+}
+
+type evaluator struct {
+	contexts map[string]any
+	options  Options
+}
+
+func newEvaluator(contexts Contexts, options Options) (*evaluator, error) {
+	e := &evaluator{contexts: map[string]any{}, options: options}
+	for name, value := range contexts {
+		normalized, err := normalize(value)
+		if err != nil {
+			return nil, fmt.Errorf("context %s: %w", name, err)
+		}
+		e.contexts[strings.ToLower(name)] = normalized
+	}
+	if e.options.Status == "" {
+		e.options.Status = "success"
+	}
+	return e, nil
+}
+
+// normalize converts Go values to the JSON data model.
+func normalize(v any) (any, error) {
+	switch v := v.(type) {
+	case nil, bool, float64, string:
+		return v, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			n, err := normalize(item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = n
+		}
+		return out, nil
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			n, err := normalize(item)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = n
+		}
+		return out, nil
+	case map[string]string:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = item
+		}
+		return out, nil
+	case []string:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = item
+		}
+		return out, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32:
+		return rv.Float(), nil
+	}
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	err = json.Unmarshal(bytes, &out)
+	return out, err
+}
+
+func (e *evaluator) eval(n node) (any, error) {
+	switch n := n.(type) {
+	case literalNode:
+		return n.value, nil
+
+	case contextNode:
+		v, ok := e.contexts[n.name]
+		if !ok {
+			return nil, fmt.Errorf("unrecognized named-value %q", n.name)
+		}
+		return v, nil
+
+	case indexNode:
+		target, err := e.eval(n.target)
+		if err != nil {
+			return nil, err
+		}
+		index, err := e.eval(n.index)
+		if err != nil {
+			return nil, err
+		}
+		return indexValue(target, index), nil
+
+	case filterNode:
+		target, err := e.eval(n.target)
+		if err != nil {
+			return nil, err
+		}
+		switch target := target.(type) {
+		case filteredArray:
+			var out filteredArray
+			for _, item := range target {
+				switch item := item.(type) {
+				case []any:
+					out = append(out, item...)
+				case map[string]any:
+					out = append(out, sortedValues(item)...)
+				}
+			}
+			return out, nil
+		case []any:
+			return filteredArray(target), nil
+		case map[string]any:
+			return filteredArray(sortedValues(target)), nil
+		default:
+			return filteredArray{}, nil
+		}
+
+	case notNode:
+		v, err := e.eval(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		return !Truthy(unwrap(v)), nil
+
+	case binaryNode:
+		left, err := e.eval(n.left)
+		if err != nil {
+			return nil, err
+		}
+		left = unwrap(left)
+		switch n.op {
+		case tokenAnd:
+			if !Truthy(left) {
+				return left, nil
+			}
+			return e.eval(n.right)
+		case tokenOr:
+			if Truthy(left) {
+				return left, nil
+			}
+			return e.eval(n.right)
+		}
+		right, err := e.eval(n.right)
+		if err != nil {
+			return nil, err
+		}
+		right = unwrap(right)
+		switch n.op {
+		case tokenEq:
+			return looseEqual(left, right), nil
+		case tokenNe:
+			return !looseEqual(left, right), nil
+		default:
+			return compare(n.op, left, right), nil
+		}
+
+	case callNode:
+		args := make([]any, len(n.args))
+		for i, arg := range n.args {
+			v, err := e.eval(arg)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = unwrap(v)
+		}
+		return functions[n.name].call(e, args)
+
+	default:
+		return nil, fmt.Errorf("unknown node %T", n)
+	}
+}
+
+func indexValue(target any, index any) any {
+	switch target := target.(type) {
+	case filteredArray:
+		out := filteredArray{}
+		for _, item := range target {
+			if v := indexValue(item, index); v != nil {
+				out = append(out, v)
+			}
+		}
+		return out
+	case []any:
+		if _, ok := index.(string); ok {
+			return nil
+		}
+		i := toNumber(index)
+		if math.IsNaN(i) || i < 0 || i != math.Trunc(i) || int(i) >= len(target) {
+			return nil
+		}
+		return target[int(i)]
+	case map[string]any:
+		key := ToString(index)
+		if v, ok := target[key]; ok {
+			return v
+		}
+		// Property names are case-insensitive.
+		keys := make([]string, 0, len(target))
+		for k := range target {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if strings.EqualFold(k, key) {
+				return target[k]
+			}
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+func sortedValues(m map[string]any) []any {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	values := make([]any, len(keys))
+	for i, k := range keys {
+		values[i] = m[k]
+	}
+	return values
+}
+
+func looseEqual(a any, b any) bool {
+	switch a := a.(type) {
+	case nil:
+		if b == nil {
+			return true
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			return a == b
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.EqualFold(a, b)
+		}
+	case float64:
+		if b, ok := b.(float64); ok {
+			return a == b
+		}
+	case []any, map[string]any:
+		// Arrays and objects are only equal to themselves. Empty ones can
+		// share a pointer without being the same value, so they never are.
+		av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+		if reflect.TypeOf(b) != av.Type() || av.Len() == 0 || av.Len() != bv.Len() {
+			return false
+		}
+		return av.UnsafePointer() == bv.UnsafePointer()
+	}
+	if isComposite(b) {
+		return false
+	}
+	return toNumber(a) == toNumber(b)
+}
+
+func isComposite(v any) bool {
+	switch v.(type) {
+	case []any, map[string]any:
+		return true
+	default:
+		return false
+	}
+}
+
+func compare(op tokenKind, a any, b any) bool {
+	if as, ok := a.(string); ok {
+		if bs, ok := b.(string); ok {
+			c := strings.Compare(strings.ToUpper(as), strings.ToUpper(bs))
+			switch op {
+			case tokenLT:
+				return c < 0
+			case tokenLE:
+				return c <= 0
+			case tokenGT:
+				return c > 0
+			default:
+				return c >= 0
+			}
+		}
+	}
+	if isComposite(a) || isComposite(b) {
+		return false
+	}
+	x, y := toNumber(a), toNumber(b)
+	switch op {
+	case tokenLT:
+		return x < y
+	case tokenLE:
+		return x <= y
+	case tokenGT:
+		return x > y
+	default:
+		return x >= y
+	}
+}
+
+func (e *evaluator) workspace() string {
+	if e.options.Workspace != "" {
+		return e.options.Workspace
+	}
+	if github, ok := e.contexts["github"].(map[string]any); ok {
+		if workspace, ok := indexValue(github, "workspace").(string); ok && workspace != "" {
+			return workspace
+		}
+	}
+	if workspace := os.Getenv("GITHUB_WORKSPACE"); workspace != "" {
+		return workspace
+	}
+	workspace, _ := os.Getwd()
+	return workspace
+}
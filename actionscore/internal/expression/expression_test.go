@@ -0,0 +1,183 @@
+package expression_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/expression"
+)
+
+var contexts = expression.Contexts{
+	"github": map[string]any{
+		"token":      "ghs_123",
+		"event_name": "push",
+		"ref":        "refs/heads/main",
+		"event": map[string]any{
+			"commits": []any{
+				map[string]any{"message": "first", "author": map[string]any{"name": "a"}},
+				map[string]any{"message": "second", "author": map[string]any{"name": "b"}},
+			},
+		},
+	},
+	"env":    map[string]string{"NAME": "World"},
+	"inputs": map[string]any{"count": 3, "labels": []string{"bug", "help wanted"}},
+	"runner": map[string]any{"os": "Linux"},
+	"steps": map[string]any{
+		"build": map[string]any{"outputs": map[string]any{"result": "ok"}},
+	},
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		expr string
+		want any
+	}{
+		{"null", nil},
+		{"true", true},
+		{"-1.5", -1.5},
+		{"0xff", 255.0},
+		{"'it''s'", "it's"},
+		{"github.token", "ghs_123"},
+		{"GitHub.Event_Name", "push"},
+		{"github['ref']", "refs/heads/main"},
+		{"github.missing", nil},
+		{"github.missing.deeper", nil},
+		{"env.NAME", "World"},
+		{"env.name", "World"},
+		{"inputs.count", 3.0},
+		{"inputs.labels[1]", "help wanted"},
+		{"steps.build.outputs.result", "ok"},
+		{"github.event.commits.*.message", []any{"first", "second"}},
+		{"github.event.commits[*].author.name", []any{"a", "b"}},
+		{"1 == '1'", true},
+		{"'ABC' == 'abc'", true},
+		{"null == 0", true},
+		{"'a' < 'B'", true},
+		{"1 < 2 && 2 < 3", true},
+		{"!github.missing", true},
+		{"github.missing || 'default'", "default"},
+		{"runner.os == 'Linux' && 'yes' || 'no'", "yes"},
+		{"(1 == 2) != false", false},
+		{"contains(inputs.labels, 'BUG')", true},
+		{"contains('Hello World', 'world')", true},
+		{"startsWith(github.ref, 'refs/heads/')", true},
+		{"endsWith(github.ref, '/MAIN')", true},
+		{"format('Hello {0}, {{{1}}}', env.NAME, 2)", "Hello World, {2}"},
+		{"join(inputs.labels)", "bug,help wanted"},
+		{"join(inputs.labels, ' | ')", "bug | help wanted"},
+		{"toJSON(steps.build.outputs)", "{\n  \"result\": \"ok\"\n}"},
+		{"fromJSON('{\"a\":[1,true]}').a[0]", 1.0},
+		{"fromJSON('[1,2]')[5]", nil},
+		{"fromJSON('[]') == fromJSON('[]')", false},
+		{"fromJSON('{}') == fromJSON('{}')", false},
+		{"fromJSON('[1]') == fromJSON('[1]')", false},
+		{"inputs.labels == inputs.labels", true},
+		{"fromJSON('[]') == null", false},
+		{"success()", true},
+		{"failure()", false},
+		{"always()", true},
+	}
+	for _, tt := range tests {
+		got, err := expression.Evaluate(tt.expr, contexts, expression.Options{})
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %#v, got %#v", tt.expr, tt.want, got)
+		}
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"github.",
+		"'unterminated",
+		"1 +",
+		"nosuch.context",
+		"nosuch()",
+		"contains('a')",
+		"format('{1}', 'a')",
+		"fromJSON('{')",
+	}
+	for _, expr := range tests {
+		_, err := expression.Evaluate(expr, contexts, expression.Options{})
+		if err == nil {
+			t.Errorf("%q: expected an error", expr)
+		}
+	}
+}
+
+func TestStatusFunctions(t *testing.T) {
+	got, err := expression.Evaluate("failure() && !cancelled()", nil, expression.Options{Status: "failure"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != true {
+		t.Errorf("expected true, got %#v", got)
+	}
+}
+
+func TestEvaluateTemplate(t *testing.T) {
+	got, err := expression.EvaluateTemplate("token=${{ github.token }} os=${{ runner.os }} lit=${{ '}}' }}", contexts, expression.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "token=ghs_123 os=Linux lit=}}"
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	_, err = expression.EvaluateTemplate("${{ github.token", contexts, expression.Options{})
+	if err == nil {
+		t.Error("expected an error for an unclosed expression")
+	}
+}
+
+func TestHashFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.sum":              "a",
+		"sub/go.sum":          "b",
+		"vendor/dep/go.sum":   "c",
+		"sub/other/README.md": "d",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	options := expression.Options{Workspace: dir}
+
+	all, err := expression.Evaluate("hashFiles('**/go.sum')", nil, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filtered, err := expression.Evaluate("hashFiles('**/go.sum', '!vendor/**')", nil, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all.(string)) != 64 || len(filtered.(string)) != 64 {
+		t.Errorf("expected SHA-256 hex digests, got %q and %q", all, filtered)
+	}
+	if all == filtered {
+		t.Errorf("expected excluding vendor/ to change the hash")
+	}
+
+	none, err := expression.Evaluate("hashFiles('*.lock')", nil, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if none != "" {
+		t.Errorf("expected %q, got %q", "", none)
+	}
+}
@@ -0,0 +1,285 @@
+package expression
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type function struct {
+	minArgs int
+	// maxArgs is -1 for variadic functions.
+	maxArgs int
+	call    func(e *evaluator, args []any) (any, error)
+}
+
+// functions is keyed by lowercased name; function names are case-insensitive.
+var functions = map[string]function{
+	"contains":   {2, 2, contains},
+	"startswith": {2, 2, startsWith},
+	"endswith":   {2, 2, endsWith},
+	"format":     {1, -1, format},
+	"join":       {1, 2, join},
+	"tojson":     {1, 1, toJSON},
+	"fromjson":   {1, 1, fromJSON},
+	"hashfiles":  {1, -1, hashFiles},
+	"success":    {0, 0, status("success")},
+	"failure":    {0, 0, status("failure")},
+	"cancelled":  {0, 0, status("cancelled")},
+	"always":     {0, 0, always},
+}
+
+func contains(e *evaluator, args []any) (any, error) {
+	if items, ok := args[0].([]any); ok {
+		for _, item := range items {
+			if looseEqual(item, args[1]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return strings.Contains(strings.ToLower(ToString(args[0])), strings.ToLower(ToString(args[1]))), nil
+}
+
+func startsWith(e *evaluator, args []any) (any, error) {
+	return strings.HasPrefix(strings.ToLower(ToString(args[0])), strings.ToLower(ToString(args[1]))), nil
+}
+
+func endsWith(e *evaluator, args []any) (any, error) {
+	return strings.HasSuffix(strings.ToLower(ToString(args[0])), strings.ToLower(ToString(args[1]))), nil
+}
+
+// format replaces {N} with the Nth argument after the format string. {{ and }}
+// escape literal braces.
+func format(e *evaluator, args []any) (any, error) {
+	f := ToString(args[0])
+	var b strings.Builder
+	for i := 0; i < len(f); i++ {
+		switch {
+		case strings.HasPrefix(f[i:], "{{"):
+			b.WriteByte('{')
+			i++
+		case strings.HasPrefix(f[i:], "}}"):
+			b.WriteByte('}')
+			i++
+		case f[i] == '{':
+			end := strings.IndexByte(f[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid format string %q", f)
+			}
+			n, err := strconv.Atoi(f[i+1 : i+end])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid format string %q", f)
+			}
+			if n+1 >= len(args) {
+				return nil, fmt.Errorf("format string %q references argument %d, but only %d were given", f, n, len(args)-1)
+			}
+			b.WriteString(ToString(args[n+1]))
+			i += end
+		case f[i] == '}':
+			return nil, fmt.Errorf("invalid format string %q", f)
+		default:
+			b.WriteByte(f[i])
+		}
+	}
+	return b.String(), nil
+}
+
+func join(e *evaluator, args []any) (any, error) {
+	separator := ","
+	if len(args) > 1 {
+		separator = ToString(args[1])
+	}
+	items, ok := args[0].([]any)
+	if !ok {
+		return ToString(args[0]), nil
+	}
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = ToString(item)
+	}
+	return strings.Join(parts, separator), nil
+}
+
+func toJSON(e *evaluator, args []any) (any, error) {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(jsonValue(args[0]))
+	if err != nil {
+		return nil, err
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// jsonValue replaces numbers JSON can't represent with null.
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+		return v
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = jsonValue(item)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = jsonValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func fromJSON(e *evaluator, args []any) (any, error) {
+	s := strings.TrimSpace(ToString(args[0]))
+	if s == "" {
+		return nil, fmt.Errorf("fromJSON: empty input")
+	}
+	var v any
+	err := json.Unmarshal([]byte(s), &v)
+	if err != nil {
+		return nil, fmt.Errorf("fromJSON: %w", err)
+	}
+	return v, nil
+}
+
+// hashFiles returns the SHA-256 of the SHA-256 of every file matching the
+// patterns, in path order, or "" when nothing matches. Patterns are relative
+// to the workspace, support ** and are excluded with a leading !.
+func hashFiles(e *evaluator, args []any) (any, error) {
+	root := e.workspace()
+	var include, exclude []string
+	for _, arg := range args {
+		pattern := strings.TrimSpace(ToString(arg))
+		negate := strings.HasPrefix(pattern, "!")
+		if negate {
+			pattern = strings.TrimSpace(pattern[1:])
+		}
+		pattern = filepath.ToSlash(pattern)
+		if filepath.IsAbs(filepath.FromSlash(pattern)) {
+			rel, err := filepath.Rel(root, filepath.FromSlash(pattern))
+			if err != nil {
+				return nil, fmt.Errorf("hashFiles: %w", err)
+			}
+			pattern = filepath.ToSlash(rel)
+		}
+		pattern = strings.TrimPrefix(pattern, "./")
+		if negate {
+			exclude = append(exclude, pattern)
+		} else {
+			include = append(include, pattern)
+		}
+	}
+
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if matchAny(include, rel) && !matchAny(exclude, rel) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("hashFiles: %w", err)
+	}
+	if len(files) == 0 {
+		return "", nil
+	}
+	sort.Strings(files)
+
+	result := sha256.New()
+	for _, file := range files {
+		h, err := hashFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("hashFiles: %w", err)
+		}
+		result.Write(h)
+	}
+	return hex.EncodeToString(result.Sum(nil)), nil
+}
+
+func hashFile(name string) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(strings.Split(pattern, "/"), strings.Split(name, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches path segments against pattern segments, where a ** segment
+// matches any number of segments.
+func matchGlob(pattern []string, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchGlob(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		ok, err := path.Match(pattern[0], name[0])
+		if err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+func status(want string) func(e *evaluator, args []any) (any, error) {
+	return func(e *evaluator, args []any) (any, error) {
+		return strings.EqualFold(e.options.Status, want), nil
+	}
+}
+
+func always(e *evaluator, args []any) (any, error) {
+	return true, nil
+}
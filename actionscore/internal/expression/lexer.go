@@ -0,0 +1,224 @@
+package expression
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNull
+	tokenBool
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenDot
+	tokenComma
+	tokenStar
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+	tokenNot
+	tokenLT
+	tokenLE
+	tokenGT
+	tokenGE
+	tokenEq
+	tokenNe
+	tokenAnd
+	tokenOr
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value any
+	pos   int
+}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '\'':
+			var b strings.Builder
+			j := i + 1
+			for {
+				if j >= len(src) {
+					return nil, fmt.Errorf("unterminated string at position %d", i+1)
+				}
+				if src[j] == '\'' {
+					if j+1 < len(src) && src[j+1] == '\'' {
+						b.WriteByte('\'')
+						j += 2
+						continue
+					}
+					break
+				}
+				b.WriteByte(src[j])
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenString, text: src[i : j+1], value: b.String(), pos: i})
+			i = j + 1
+			continue
+		case startsNumber(src, i, tokens):
+			j := i + 1
+			for j < len(src) && (isIdentChar(src[j]) || src[j] == '.' || (src[j] == '-' || src[j] == '+') && (src[j-1] == 'e' || src[j-1] == 'E')) {
+				j++
+			}
+			text := src[i:j]
+			n, err := parseNumber(text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", text, i+1)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: text, value: n, pos: i})
+			i = j
+			continue
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(src) && isIdentChar(src[j]) {
+				j++
+			}
+			text := src[i:j]
+			tok := token{kind: tokenIdent, text: text, pos: i}
+			// Keywords are only keywords where a value is expected, so
+			// github.event.null still works as a property name.
+			if len(tokens) == 0 || tokens[len(tokens)-1].kind != tokenDot {
+				switch text {
+				case "null":
+					tok.kind = tokenNull
+				case "true":
+					tok.kind, tok.value = tokenBool, true
+				case "false":
+					tok.kind, tok.value = tokenBool, false
+				case "NaN", "Infinity":
+					n, _ := parseNumber(text)
+					tok.kind, tok.value = tokenNumber, n
+				}
+			}
+			tokens = append(tokens, tok)
+			i = j
+			continue
+		}
+
+		if i+1 < len(src) {
+			if kind, ok := twoCharOperators[src[i:i+2]]; ok {
+				tokens = append(tokens, token{kind: kind, text: src[i : i+2], pos: i})
+				i += 2
+				continue
+			}
+		}
+
+		kind, ok := oneCharOperators[c]
+		if !ok {
+			return nil, fmt.Errorf("unexpected character %q at position %d", c, i+1)
+		}
+		tokens = append(tokens, token{kind: kind, text: string(c), pos: i})
+		i++
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+var twoCharOperators = map[string]tokenKind{
+	"<=": tokenLE,
+	">=": tokenGE,
+	"==": tokenEq,
+	"!=": tokenNe,
+	"&&": tokenAnd,
+	"||": tokenOr,
+}
+
+var oneCharOperators = map[byte]tokenKind{
+	'.': tokenDot,
+	',': tokenComma,
+	'*': tokenStar,
+	'(': tokenLParen,
+	')': tokenRParen,
+	'[': tokenLBracket,
+	']': tokenRBracket,
+	'!': tokenNot,
+	'<': tokenLT,
+	'>': tokenGT,
+}
+
+func startsNumber(src string, i int, tokens []token) bool {
+	c := src[i]
+	if isDigit(c) {
+		return true
+	}
+	if precedesValue(tokens) || i+1 >= len(src) {
+		return false
+	}
+	next := src[i+1]
+	switch c {
+	case '-', '+':
+		return isDigit(next) || next == '.'
+	case '.':
+		return isDigit(next)
+	default:
+		return false
+	}
+}
+
+// precedesValue reports whether the last token ends a value, in which case a
+// following sign or dot is an operator rather than part of a number.
+func precedesValue(tokens []token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	switch tokens[len(tokens)-1].kind {
+	case tokenNull, tokenBool, tokenNumber, tokenString, tokenIdent, tokenRParen, tokenRBracket, tokenStar:
+		return true
+	default:
+		return false
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '-'
+}
+
+func parseNumber(text string) (float64, error) {
+	sign := 1.0
+	unsigned := text
+	if strings.HasPrefix(unsigned, "-") {
+		sign, unsigned = -1, unsigned[1:]
+	} else if strings.HasPrefix(unsigned, "+") {
+		unsigned = unsigned[1:]
+	}
+	switch {
+	case strings.HasPrefix(unsigned, "0x") || strings.HasPrefix(unsigned, "0X"):
+		n, err := strconv.ParseUint(unsigned[2:], 16, 64)
+		return sign * float64(n), err
+	case strings.HasPrefix(unsigned, "0o") || strings.HasPrefix(unsigned, "0O"):
+		n, err := strconv.ParseUint(unsigned[2:], 8, 64)
+		return sign * float64(n), err
+	case unsigned == "Infinity":
+		return sign * posInf, nil
+	case unsigned == "NaN":
+		return nan, nil
+	}
+	for _, c := range unsigned {
+		if !(c >= '0' && c <= '9' || c == '.' || c == 'e' || c == 'E' || c == '-' || c == '+') {
+			return 0, strconv.ErrSyntax
+		}
+	}
+	n, err := strconv.ParseFloat(unsigned, 64)
+	return sign * n, err
+}
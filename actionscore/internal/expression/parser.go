@@ -0,0 +1,251 @@
+package expression
+
+import (
+	"fmt"
+	"strings"
+)
+
+type node interface{}
+
+type (
+	literalNode struct{ value any }
+	contextNode struct{ name string }
+	indexNode   struct {
+		target node
+		index  node
+	}
+	// filterNode is the .* / [*] object filter.
+	filterNode struct{ target node }
+	notNode    struct{ operand node }
+	binaryNode struct {
+		op          tokenKind
+		left, right node
+	}
+	callNode struct {
+		name string
+		args []node
+	}
+)
+
+type parser struct {
+	tokens []token
+	pos    int
+	src    string
+}
+
+func parse(src string) (node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, src: src}
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.unexpected(tok)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		if tok.kind == tokenEOF {
+			return tok, fmt.Errorf("expected %s at end of expression", what)
+		}
+		return tok, fmt.Errorf("expected %s, got %q at position %d", what, tok.text, tok.pos+1)
+	}
+	return tok, nil
+}
+
+func (p *parser) unexpected(tok token) error {
+	if tok.kind == tokenEOF {
+		return fmt.Errorf("unexpected end of expression")
+	}
+	return fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos+1)
+}
+
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: tokenOr, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (node, error) {
+	left, err := p.equality()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenAnd {
+		p.next()
+		right, err := p.equality()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: tokenAnd, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) equality() (node, error) {
+	left, err := p.comparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenEq || p.peek().kind == tokenNe {
+		op := p.next().kind
+		right, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) comparison() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokenLT, tokenLE, tokenGT, tokenGE:
+			op := p.next().kind
+			right, err := p.unary()
+			if err != nil {
+				return nil, err
+			}
+			left = binaryNode{op: op, left: left, right: right}
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	if p.peek().kind == tokenNot {
+		p.next()
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return p.postfix()
+}
+
+func (p *parser) postfix() (node, error) {
+	n, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokenDot:
+			p.next()
+			tok := p.next()
+			switch tok.kind {
+			case tokenStar:
+				n = filterNode{target: n}
+			case tokenIdent:
+				n = indexNode{target: n, index: literalNode{value: tok.text}}
+			default:
+				return nil, fmt.Errorf("expected a property name after '.' at position %d", tok.pos+1)
+			}
+		case tokenLBracket:
+			p.next()
+			if p.peek().kind == tokenStar {
+				p.next()
+				n = filterNode{target: n}
+			} else {
+				index, err := p.or()
+				if err != nil {
+					return nil, err
+				}
+				n = indexNode{target: n, index: index}
+			}
+			_, err := p.expect(tokenRBracket, "']'")
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return n, nil
+		}
+	}
+}
+
+func (p *parser) primary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNull:
+		return literalNode{value: nil}, nil
+	case tokenBool, tokenNumber, tokenString:
+		return literalNode{value: tok.value}, nil
+	case tokenLParen:
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.expect(tokenRParen, "')'")
+		return n, err
+	case tokenIdent:
+		if p.peek().kind != tokenLParen {
+			return contextNode{name: strings.ToLower(tok.text)}, nil
+		}
+		p.next()
+		var args []node
+		if p.peek().kind != tokenRParen {
+			for {
+				arg, err := p.or()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+				if p.peek().kind != tokenComma {
+					break
+				}
+				p.next()
+			}
+		}
+		_, err := p.expect(tokenRParen, "')'")
+		if err != nil {
+			return nil, err
+		}
+		name := strings.ToLower(tok.text)
+		fn, ok := functions[name]
+		if !ok {
+			return nil, fmt.Errorf("unrecognized function %q", tok.text)
+		}
+		if len(args) < fn.minArgs || fn.maxArgs >= 0 && len(args) > fn.maxArgs {
+			return nil, fmt.Errorf("wrong number of arguments to %s: %d", tok.text, len(args))
+		}
+		return callNode{name: name, args: args}, nil
+	default:
+		return nil, p.unexpected(tok)
+	}
+}
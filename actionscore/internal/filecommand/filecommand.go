@@ -1 +1,100 @@
 package filecommand
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/filelock"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/utils"
+)
+
+var eol = func() string {
+	if runtime.GOOS == "windows" {
+		return "\r\n"
+	} else {
+		return "\n"
+	}
+}()
+
+func IssueFileCommand(command string, message any) error {
+	filePath := os.Getenv("GITHUB_" + command)
+	if filePath == "" {
+		return fmt.Errorf("unable to find environment variable for file command %s", command)
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		return fmt.Errorf("missing file at path: %s", filePath)
+	}
+
+	messageStr, err := utils.ToCommandValue(message)
+	if err != nil {
+		return err
+	}
+	return filelock.AppendFile(filePath, []byte(messageStr+eol))
+}
+
+func PrepareKeyValueMessage(key string, value any) (string, error) {
+	delimiter := "ghadelimiter_" + uuid.NewString()
+	convertedValue, err := utils.ToCommandValue(value)
+	if err != nil {
+		return "", err
+	}
+
+	// These should realistically never happen, but just in case someone finds
+	// a way to exploit uuid generation let's not allow keys or values that
+	// contain the delimiter.
+	if strings.Contains(key, delimiter) {
+		return "", fmt.Errorf("unexpected input: name should not contain the delimiter %q", delimiter)
+	}
+	if strings.Contains(convertedValue, delimiter) {
+		return "", fmt.Errorf("unexpected input: value should not contain the delimiter %q", delimiter)
+	}
+
+	return key + "<<" + delimiter + eol + convertedValue + eol + delimiter, nil
+}
+
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// ParseKeyValueMessages reads a GITHUB_ENV, GITHUB_OUTPUT or GITHUB_STATE file
+// the way the runner does, accepting both "key=value" lines and the
+// "key<<delimiter" heredoc form written by PrepareKeyValueMessage.
+func ParseKeyValueMessages(content string) ([]KeyValue, error) {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	var messages []KeyValue
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" {
+			continue
+		}
+		if key, delimiter, ok := strings.Cut(line, "<<"); ok && !strings.Contains(key, "=") {
+			if key == "" || delimiter == "" {
+				return nil, fmt.Errorf("invalid format %q on line %d", line, i+1)
+			}
+			var value []string
+			closed := false
+			for i++; i < len(lines); i++ {
+				if lines[i] == delimiter {
+					closed = true
+					break
+				}
+				value = append(value, lines[i])
+			}
+			if !closed {
+				return nil, fmt.Errorf("matching delimiter not found %q", delimiter)
+			}
+			messages = append(messages, KeyValue{Key: key, Value: strings.Join(value, "\n")})
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid format %q on line %d", line, i+1)
+		}
+		messages = append(messages, KeyValue{Key: key, Value: value})
+	}
+	return messages, nil
+}
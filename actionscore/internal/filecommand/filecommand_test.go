@@ -0,0 +1,62 @@
+package filecommand_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"testing"
+
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/filecommand"
+)
+
+var eol = func() string {
+	if runtime.GOOS == "windows" {
+		return "\r\n"
+	}
+	return "\n"
+}()
+
+func TestPrepareKeyValueMessage(t *testing.T) {
+	message, err := filecommand.PrepareKeyValueMessage("my-output", "line 1\nline 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	re := regexp.MustCompile(`^my-output<<(ghadelimiter_[0-9a-f-]{36})` + eol + "line 1\nline 2" + eol + `(ghadelimiter_[0-9a-f-]{36})$`)
+	match := re.FindStringSubmatch(message)
+	if match == nil {
+		t.Fatalf("unexpected message %q", message)
+	}
+	if match[1] != match[2] {
+		t.Errorf("expected matching delimiters, got %q and %q", match[1], match[2])
+	}
+}
+
+func TestIssueFileCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output")
+	err := os.WriteFile(path, nil, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_OUTPUT", path)
+
+	err = filecommand.IssueFileCommand("OUTPUT", map[string]int{"a": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"a":1}` + eol
+	if string(bytes) != expected {
+		t.Errorf("expected %q, got %q", expected, string(bytes))
+	}
+}
+
+func TestIssueFileCommandMissingEnv(t *testing.T) {
+	t.Setenv("GITHUB_OUTPUT", "")
+	if err := filecommand.IssueFileCommand("OUTPUT", "x"); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,61 @@
+// Package filelock serializes writes to GITHUB_STEP_SUMMARY and the file
+// command files, which goroutines and child processes of one action may all
+// append to at once.
+package filelock
+
+import "os"
+
+// AppendFile appends data to the file at path while holding an exclusive
+// advisory lock on it. Like the runner, it expects the file to exist already.
+func AppendFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	err = lock(f)
+	if err != nil {
+		return err
+	}
+	defer unlock(f)
+	_, err = f.Write(data)
+	return err
+}
+
+// WriteFile replaces the contents of the file at path while holding an
+// exclusive advisory lock on it. The file is only truncated once the lock is
+// held so that concurrent appenders can't have their writes cut in half.
+func WriteFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	err = lock(f)
+	if err != nil {
+		return err
+	}
+	defer unlock(f)
+	err = f.Truncate(0)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// WithLock calls fn with the file at path open for appending and exclusively
+// locked, for callers that need to read the current size before writing.
+func WithLock(path string, fn func(f *os.File) error) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	err = lock(f)
+	if err != nil {
+		return err
+	}
+	defer unlock(f)
+	return fn(f)
+}
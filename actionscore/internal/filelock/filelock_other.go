@@ -0,0 +1,22 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package filelock
+
+import (
+	"os"
+	"sync"
+)
+
+// Without flock we can at least keep goroutines in this process from
+// interleaving their writes.
+var mu sync.Mutex
+
+func lock(f *os.File) error {
+	mu.Lock()
+	return nil
+}
+
+func unlock(f *os.File) error {
+	mu.Unlock()
+	return nil
+}
@@ -0,0 +1,70 @@
+package filelock_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/filelock"
+)
+
+func TestAppendFileConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "env")
+	err := os.WriteFile(path, nil, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const workers = 16
+	const writes = 20
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			line := strings.Repeat(string(rune('a'+i)), 8192) + "\n"
+			for j := 0; j < writes; j++ {
+				if err := filelock.AppendFile(path, []byte(line)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(bytes), "\n"), "\n")
+	if len(lines) != workers*writes {
+		t.Fatalf("expected %d lines, got %d", workers*writes, len(lines))
+	}
+	for i, line := range lines {
+		if len(line) != 8192 || strings.Trim(line, line[:1]) != "" {
+			t.Fatalf("line %d was interleaved with another write", i)
+		}
+	}
+}
+
+func TestWriteFileTruncates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary")
+	err := os.WriteFile(path, []byte("old contents"), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = filelock.WriteFile(path, []byte("new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bytes) != "new" {
+		t.Errorf("expected %q, got %q", "new", string(bytes))
+	}
+}
@@ -0,0 +1,373 @@
+package oidcutils
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	Issuer  = "https://token.actions.githubusercontent.com"
+	JWKSURL = Issuer + "/.well-known/jwks"
+)
+
+// Claims are the claims of a GitHub Actions OIDC ID token. See
+// https://docs.github.com/actions/security-for-github-actions/security-hardening-your-deployments/about-security-hardening-with-openid-connect.
+type Claims struct {
+	Issuer    string      `json:"iss"`
+	Subject   string      `json:"sub"`
+	Audience  Audience    `json:"aud"`
+	ExpiresAt NumericDate `json:"exp"`
+	NotBefore NumericDate `json:"nbf"`
+	IssuedAt  NumericDate `json:"iat"`
+	ID        string      `json:"jti"`
+
+	Actor                string `json:"actor"`
+	ActorID              string `json:"actor_id"`
+	BaseRef              string `json:"base_ref"`
+	Environment          string `json:"environment"`
+	EventName            string `json:"event_name"`
+	HeadRef              string `json:"head_ref"`
+	JobWorkflowRef       string `json:"job_workflow_ref"`
+	JobWorkflowSHA       string `json:"job_workflow_sha"`
+	Ref                  string `json:"ref"`
+	RefProtected         string `json:"ref_protected"`
+	RefType              string `json:"ref_type"`
+	Repository           string `json:"repository"`
+	RepositoryID         string `json:"repository_id"`
+	RepositoryOwner      string `json:"repository_owner"`
+	RepositoryOwnerID    string `json:"repository_owner_id"`
+	RepositoryVisibility string `json:"repository_visibility"`
+	RunAttempt           string `json:"run_attempt"`
+	RunID                string `json:"run_id"`
+	RunNumber            string `json:"run_number"`
+	RunnerEnvironment    string `json:"runner_environment"`
+	SHA                  string `json:"sha"`
+	Workflow             string `json:"workflow"`
+	WorkflowRef          string `json:"workflow_ref"`
+	WorkflowSHA          string `json:"workflow_sha"`
+
+	// Raw has every claim, including ones without a field above.
+	Raw map[string]any `json:"-"`
+}
+
+// Audience is the aud claim, which may be a single string or an array.
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		*a = Audience{s}
+		return nil
+	}
+	var list []string
+	err := json.Unmarshal(data, &list)
+	if err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a Audience) Contains(audience string) bool {
+	for _, aud := range a {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// NumericDate is a JWT timestamp, seconds since the Unix epoch.
+type NumericDate struct {
+	time.Time
+}
+
+// maxNumericDate is the end of the year 9999, as far as a NumericDate may go.
+const maxNumericDate = 253402300799
+
+func (d *NumericDate) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	err := json.Unmarshal(data, &seconds)
+	if err != nil {
+		return err
+	}
+	if seconds < -maxNumericDate || seconds > maxNumericDate {
+		return fmt.Errorf("JWT timestamp %v out of range", seconds)
+	}
+	whole, fraction := math.Modf(seconds)
+	d.Time = time.Unix(int64(whole), int64(fraction*float64(time.Second)))
+	return nil
+}
+
+// ParseClaims decodes the claims of a JWT without verifying it.
+func ParseClaims(token string) (Claims, error) {
+	_, payload, _, _, err := splitJWT(token)
+	if err != nil {
+		return Claims{}, err
+	}
+	return decodeClaims(payload)
+}
+
+func decodeClaims(payload []byte) (Claims, error) {
+	var claims Claims
+	err := json.Unmarshal(payload, &claims)
+	if err != nil {
+		return Claims{}, fmt.Errorf("invalid JWT claims: %w", err)
+	}
+	err = json.Unmarshal(payload, &claims.Raw)
+	if err != nil {
+		return Claims{}, fmt.Errorf("invalid JWT claims: %w", err)
+	}
+	return claims, nil
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// splitJWT returns the decoded header and payload, the signature and the
+// signed part of a compact JWS.
+func splitJWT(token string) (header jwtHeader, payload []byte, signature []byte, signed string, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return jwtHeader{}, nil, nil, "", errors.New("invalid JWT: expected 3 parts")
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return jwtHeader{}, nil, nil, "", fmt.Errorf("invalid JWT header: %w", err)
+	}
+	err = json.Unmarshal(headerJSON, &header)
+	if err != nil {
+		return jwtHeader{}, nil, nil, "", fmt.Errorf("invalid JWT header: %w", err)
+	}
+	payload, err = base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return jwtHeader{}, nil, nil, "", fmt.Errorf("invalid JWT payload: %w", err)
+	}
+	signature, err = base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return jwtHeader{}, nil, nil, "", fmt.Errorf("invalid JWT signature: %w", err)
+	}
+	return header, payload, signature, parts[0] + "." + parts[1], nil
+}
+
+// JWKS is a JSON Web Key Set, like the one GitHub publishes at JWKSURL.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg,omitempty"`
+	Use       string `json:"use,omitempty"`
+	// RSA keys.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC keys.
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+func ParseJWKS(data []byte) (JWKS, error) {
+	var jwks JWKS
+	err := json.Unmarshal(data, &jwks)
+	if err != nil {
+		return JWKS{}, fmt.Errorf("invalid JWKS: %w", err)
+	}
+	return jwks, nil
+}
+
+// FetchJWKS downloads a JWKS from url.
+func FetchJWKS(ctx context.Context, url string) (JWKS, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return JWKS{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return JWKS{}, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return JWKS{}, fmt.Errorf("failed to fetch JWKS: HTTP %d", res.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return JWKS{}, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	return ParseJWKS(data)
+}
+
+func (k JWK) publicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA exponent: %w", err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC x coordinate: %w", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC y coordinate: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+}
+
+type VerifyOptions struct {
+	// Issuer must match the iss claim. Defaults to Issuer; GitHub Enterprise
+	// Server tokens use https://<host>/_services/token.
+	Issuer *string
+	// Audience must be one of the aud claim values. It is required, since a
+	// token minted for another audience must not be accepted.
+	Audience *string
+	// Now defaults to time.Now.
+	Now func() time.Time
+	// Leeway allowed for clock skew when checking exp, nbf and iat. Defaults
+	// to a minute.
+	Leeway *time.Duration
+}
+
+// Verify checks the signature of token against jwks and validates its
+// timestamps, issuer and audience, returning its claims.
+func Verify(token string, jwks JWKS, options VerifyOptions) (Claims, error) {
+	if options.Audience == nil {
+		return Claims{}, errors.New("verifying a JWT requires an audience")
+	}
+	issuer := Issuer
+	if options.Issuer != nil {
+		issuer = *options.Issuer
+	}
+	header, payload, signature, signed, err := splitJWT(token)
+	if err != nil {
+		return Claims{}, err
+	}
+	hash, ok := map[string]crypto.Hash{
+		"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+		"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+	}[header.Algorithm]
+	if !ok {
+		return Claims{}, fmt.Errorf("unsupported JWT algorithm %q", header.Algorithm)
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	verified := false
+	for _, key := range jwks.Keys {
+		if header.KeyID != "" && key.KeyID != header.KeyID {
+			continue
+		}
+		if key.Algorithm != "" && key.Algorithm != header.Algorithm {
+			continue
+		}
+		publicKey, err := key.publicKey()
+		if err != nil {
+			continue
+		}
+		if verifySignature(publicKey, header.Algorithm, hash, digest, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		if header.KeyID != "" {
+			return Claims{}, fmt.Errorf("invalid JWT signature for key %q", header.KeyID)
+		}
+		return Claims{}, errors.New("invalid JWT signature")
+	}
+
+	claims, err := decodeClaims(payload)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	now := time.Now()
+	if options.Now != nil {
+		now = options.Now()
+	}
+	leeway := time.Minute
+	if options.Leeway != nil {
+		leeway = *options.Leeway
+	}
+	if claims.ExpiresAt.IsZero() {
+		return Claims{}, errors.New("JWT has no exp claim")
+	}
+	if now.After(claims.ExpiresAt.Add(leeway)) {
+		return Claims{}, fmt.Errorf("JWT expired at %s", claims.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	if !claims.NotBefore.IsZero() && now.Before(claims.NotBefore.Add(-leeway)) {
+		return Claims{}, fmt.Errorf("JWT not valid before %s", claims.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if !claims.IssuedAt.IsZero() && now.Before(claims.IssuedAt.Add(-leeway)) {
+		return Claims{}, fmt.Errorf("JWT issued in the future at %s", claims.IssuedAt.UTC().Format(time.RFC3339))
+	}
+	if claims.Issuer != issuer {
+		return Claims{}, fmt.Errorf("JWT issuer is %q, expected %q", claims.Issuer, issuer)
+	}
+	if !claims.Audience.Contains(*options.Audience) {
+		return Claims{}, fmt.Errorf("JWT audience is %q, expected %q", claims.Audience, *options.Audience)
+	}
+	return claims, nil
+}
+
+func verifySignature(publicKey crypto.PublicKey, algorithm string, hash crypto.Hash, digest []byte, signature []byte) bool {
+	switch publicKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(algorithm, "RS") && rsa.VerifyPKCS1v15(publicKey, hash, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		// JWS ECDSA signatures are r || s, not ASN.1.
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(algorithm, "ES") || len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(publicKey, digest, r, s)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,262 @@
+package oidcutils_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/oidcutils"
+)
+
+var now = time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func testClaims() map[string]any {
+	return map[string]any{
+		"iss":                "https://token.actions.githubusercontent.com",
+		"sub":                "repo:octo-org/octo-repo:environment:prod",
+		"aud":                "sts.amazonaws.com",
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"nbf":                now.Add(-time.Minute).Unix(),
+		"iat":                now.Unix(),
+		"repository":         "octo-org/octo-repo",
+		"ref":                "refs/heads/main",
+		"job_workflow_ref":   "octo-org/octo-automation/.github/workflows/oidc.yml@refs/heads/main",
+		"environment":        "prod",
+		"runner_environment": "github-hosted",
+		"custom":             "extra",
+	}
+}
+
+func encodeSegment(t *testing.T, v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	signed := encodeSegment(t, map[string]any{"alg": "RS256", "typ": "JWT", "kid": kid}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func rsaJWK(key *rsa.PrivateKey, kid string) oidcutils.JWK {
+	return oidcutils.JWK{
+		KeyType:   "RSA",
+		KeyID:     kid,
+		Algorithm: "RS256",
+		N:         base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func generateRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestParseClaims(t *testing.T) {
+	token := signRS256(t, generateRSAKey(t), "k", testClaims())
+	claims, err := oidcutils.ParseClaims(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "repo:octo-org/octo-repo:environment:prod" || claims.Repository != "octo-org/octo-repo" || claims.Environment != "prod" || claims.RunnerEnvironment != "github-hosted" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if !claims.ExpiresAt.Equal(now.Add(5 * time.Minute)) {
+		t.Errorf("expected exp %s, got %s", now.Add(5*time.Minute), claims.ExpiresAt)
+	}
+	if !claims.Audience.Contains("sts.amazonaws.com") {
+		t.Errorf("expected aud to contain %q, got %q", "sts.amazonaws.com", claims.Audience)
+	}
+	if claims.Raw["custom"] != "extra" {
+		t.Errorf("expected raw claim %q, got %v", "extra", claims.Raw["custom"])
+	}
+
+	_, err = oidcutils.ParseClaims("not.a-jwt")
+	if err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	key := generateRSAKey(t)
+	other := generateRSAKey(t)
+	jwks := oidcutils.JWKS{Keys: []oidcutils.JWK{rsaJWK(other, "other"), rsaJWK(key, "main")}}
+	clock := func() time.Time { return now }
+	issuer := oidcutils.Issuer
+	audience := "sts.amazonaws.com"
+	options := oidcutils.VerifyOptions{Issuer: &issuer, Audience: &audience, Now: clock}
+
+	claims, err := oidcutils.Verify(signRS256(t, key, "main", testClaims()), jwks, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.JobWorkflowRef != "octo-org/octo-automation/.github/workflows/oidc.yml@refs/heads/main" {
+		t.Errorf("unexpected job_workflow_ref %q", claims.JobWorkflowRef)
+	}
+
+	expired := testClaims()
+	expired["exp"] = now.Add(-time.Hour).Unix()
+	wrongAudience := testClaims()
+	wrongAudience["aud"] = []string{"api://AzureADTokenExchange"}
+	wrongIssuer := testClaims()
+	wrongIssuer["iss"] = "https://example.com"
+	notYet := testClaims()
+	notYet["nbf"] = now.Add(time.Hour).Unix()
+
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{"wrong key", signRS256(t, other, "main", testClaims()), "invalid JWT signature"},
+		{"unknown kid", signRS256(t, key, "missing", testClaims()), "invalid JWT signature"},
+		{"expired", signRS256(t, key, "main", expired), "expired"},
+		{"not yet valid", signRS256(t, key, "main", notYet), "not valid before"},
+		{"audience", signRS256(t, key, "main", wrongAudience), "audience"},
+		{"issuer", signRS256(t, key, "main", wrongIssuer), "issuer"},
+	}
+	for _, tt := range tests {
+		_, err := oidcutils.Verify(tt.token, jwks, options)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	// A tampered payload must not verify.
+	token := signRS256(t, key, "main", testClaims())
+	parts := strings.Split(token, ".")
+	tampered := testClaims()
+	tampered["sub"] = "repo:evil/repo:ref:refs/heads/main"
+	parts[1] = encodeSegment(t, tampered)
+	_, err = oidcutils.Verify(strings.Join(parts, "."), jwks, options)
+	if err == nil {
+		t.Error("expected a tampered token to fail")
+	}
+
+	// The issuer defaults to GitHub's, and the audience is required.
+	_, err = oidcutils.Verify(signRS256(t, key, "main", testClaims()), jwks, oidcutils.VerifyOptions{Audience: &audience, Now: clock})
+	if err != nil {
+		t.Errorf("expected the default issuer to match, got %v", err)
+	}
+	_, err = oidcutils.Verify(signRS256(t, key, "main", wrongIssuer), jwks, oidcutils.VerifyOptions{Audience: &audience, Now: clock})
+	if err == nil || !strings.Contains(err.Error(), "issuer") {
+		t.Errorf("expected an issuer error, got %v", err)
+	}
+	_, err = oidcutils.Verify(signRS256(t, key, "main", testClaims()), jwks, oidcutils.VerifyOptions{Issuer: &issuer, Now: clock})
+	if err == nil || !strings.Contains(err.Error(), "audience") {
+		t.Errorf("expected an audience error, got %v", err)
+	}
+}
+
+func TestNumericDate(t *testing.T) {
+	tests := []struct {
+		json string
+		want time.Time
+	}{
+		{"1727784000", time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)},
+		{"1727784000.25", time.Date(2024, 10, 1, 12, 0, 0, 250_000_000, time.UTC)},
+		{"-1.5", time.Date(1969, 12, 31, 23, 59, 58, 500_000_000, time.UTC)},
+		{"253402300799", time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		var d oidcutils.NumericDate
+		err := json.Unmarshal([]byte(tt.json), &d)
+		if err != nil {
+			t.Errorf("%s: %v", tt.json, err)
+			continue
+		}
+		if !d.Equal(tt.want) {
+			t.Errorf("%s: expected %s, got %s", tt.json, tt.want, d.UTC())
+		}
+	}
+
+	// Past the year 9999, and past what int64 nanoseconds can hold.
+	for _, data := range []string{"253402300800", "1e19", "-1e30"} {
+		var d oidcutils.NumericDate
+		err := json.Unmarshal([]byte(data), &d)
+		if err == nil {
+			t.Errorf("%s: expected an error, got %s", data, d.UTC())
+		}
+	}
+}
+
+func TestVerifyES256(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := encodeSegment(t, map[string]any{"alg": "ES256", "kid": "ec"}) + "." + encodeSegment(t, testClaims())
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	token := signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+
+	x, y := make([]byte, 32), make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+	jwks := oidcutils.JWKS{Keys: []oidcutils.JWK{{
+		KeyType: "EC",
+		KeyID:   "ec",
+		Curve:   "P-256",
+		X:       base64.RawURLEncoding.EncodeToString(x),
+		Y:       base64.RawURLEncoding.EncodeToString(y),
+	}}}
+	_, err = oidcutils.Verify(token, jwks, oidcutils.VerifyOptions{Audience: ptr("sts.amazonaws.com"), Now: func() time.Time { return now }})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFetchJWKS(t *testing.T) {
+	key := generateRSAKey(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/jwks" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(oidcutils.JWKS{Keys: []oidcutils.JWK{rsaJWK(key, "main")}})
+	}))
+	defer server.Close()
+
+	jwks, err := oidcutils.FetchJWKS(context.Background(), server.URL+"/.well-known/jwks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = oidcutils.Verify(signRS256(t, key, "main", testClaims()), jwks, oidcutils.VerifyOptions{Audience: ptr("sts.amazonaws.com"), Now: func() time.Time { return now }})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = oidcutils.FetchJWKS(context.Background(), server.URL+"/missing")
+	if err == nil {
+		t.Error("expected an error for a 404")
+	}
+}
@@ -1 +1,121 @@
 package oidcutils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/command"
+)
+
+// ErrIDTokenUnavailable is returned when the runner won't hand out ID tokens,
+// which is what happens when the job lacks the id-token: write permission.
+var ErrIDTokenUnavailable = errors.New("ID token unavailable: make sure the workflow or job has \"permissions: id-token: write\"")
+
+const (
+	maxRetries  = 10
+	retryDelay  = 5 * time.Millisecond
+	maxBackoffN = 10
+)
+
+var httpClient = &http.Client{Timeout: 60 * time.Second}
+
+type tokenResponse struct {
+	Value string `json:"value"`
+}
+
+// GetIDToken requests a GitHub OIDC ID token for audience (or the default
+// audience if nil) and masks it.
+func GetIDToken(ctx context.Context, audience *string) (string, error) {
+	requestURL := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+	requestToken := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+	if requestURL == "" || requestToken == "" {
+		return "", fmt.Errorf("%w (ACTIONS_ID_TOKEN_REQUEST_URL or ACTIONS_ID_TOKEN_REQUEST_TOKEN is not set)", ErrIDTokenUnavailable)
+	}
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid ACTIONS_ID_TOKEN_REQUEST_URL: %w", err)
+	}
+	if audience != nil {
+		query := u.Query()
+		query.Set("audience", *audience)
+		u.RawQuery = query.Encode()
+	}
+	err = command.IssueCommand("debug", command.CommandProperties{}, "ID token url is "+u.String())
+	if err != nil {
+		return "", err
+	}
+
+	token, err := requestIDToken(ctx, u.String(), requestToken)
+	if err != nil {
+		return "", err
+	}
+	err = command.IssueCommand("add-mask", command.CommandProperties{}, token)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func requestIDToken(ctx context.Context, requestURL string, requestToken string) (string, error) {
+	for attempt := 0; ; attempt++ {
+		token, retry, err := tryRequestIDToken(ctx, requestURL, requestToken)
+		if err == nil {
+			return token, nil
+		}
+		if !retry || attempt >= maxRetries {
+			return "", err
+		}
+		// Exponential backoff, like @actions/http-client.
+		delay := retryDelay << min(attempt+1, maxBackoffN)
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func tryRequestIDToken(ctx context.Context, requestURL string, requestToken string) (token string, retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return "", false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+requestToken)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "actions/oidc-client")
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return "", ctx.Err() == nil, fmt.Errorf("failed to get ID token: %w", err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return "", true, fmt.Errorf("failed to get ID token: %w", err)
+	}
+
+	switch {
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return "", false, fmt.Errorf("%w (HTTP %d: %s)", ErrIDTokenUnavailable, res.StatusCode, body)
+	case res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500:
+		return "", true, fmt.Errorf("failed to get ID token: HTTP %d: %s", res.StatusCode, body)
+	case res.StatusCode != http.StatusOK:
+		return "", false, fmt.Errorf("failed to get ID token: HTTP %d: %s", res.StatusCode, body)
+	}
+
+	var response tokenResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get ID token: %w", err)
+	}
+	if response.Value == "" {
+		return "", false, errors.New("failed to get ID token: response json body does not have a value")
+	}
+	return response.Value, false, nil
+}
@@ -0,0 +1,141 @@
+package oidcutils_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/command"
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/oidcutils"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func setup(t *testing.T, handler http.HandlerFunc) *bytes.Buffer {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", server.URL+"/token?api-version=2.0")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "request-token")
+	var out bytes.Buffer
+	t.Cleanup(command.SetOutput(&out))
+	return &out
+}
+
+func TestGetIDToken(t *testing.T) {
+	out := setup(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer request-token" {
+			t.Errorf("expected %q, got %q", "Bearer request-token", got)
+		}
+		if got := r.URL.Query().Get("api-version"); got != "2.0" {
+			t.Errorf("expected api-version %q, got %q", "2.0", got)
+		}
+		if got := r.URL.Query().Get("audience"); got != "sts.amazonaws.com" {
+			t.Errorf("expected audience %q, got %q", "sts.amazonaws.com", got)
+		}
+		w.Write([]byte(`{"count":1,"value":"id-token"}`))
+	})
+
+	token, err := oidcutils.GetIDToken(context.Background(), ptr("sts.amazonaws.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "id-token" {
+		t.Errorf("expected %q, got %q", "id-token", token)
+	}
+	if !strings.Contains(out.String(), "::add-mask::id-token") {
+		t.Errorf("expected the token to be masked, got %q", out.String())
+	}
+}
+
+func TestGetIDTokenNoAudience(t *testing.T) {
+	setup(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("audience") {
+			t.Errorf("expected no audience, got %q", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"value":"id-token"}`))
+	})
+
+	_, err := oidcutils.GetIDToken(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetIDTokenRetries(t *testing.T) {
+	attempts := 0
+	setup(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"value":"id-token"}`))
+	})
+
+	token, err := oidcutils.GetIDToken(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "id-token" || attempts != 3 {
+		t.Errorf("expected the token after 3 attempts, got %q after %d", token, attempts)
+	}
+}
+
+func TestGetIDTokenForbidden(t *testing.T) {
+	attempts := 0
+	setup(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		http.Error(w, "nope", http.StatusForbidden)
+	})
+
+	_, err := oidcutils.GetIDToken(context.Background(), nil)
+	if !errors.Is(err, oidcutils.ErrIDTokenUnavailable) {
+		t.Errorf("expected ErrIDTokenUnavailable, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestGetIDTokenMissingPermission(t *testing.T) {
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", "")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "")
+
+	_, err := oidcutils.GetIDToken(context.Background(), nil)
+	if !errors.Is(err, oidcutils.ErrIDTokenUnavailable) {
+		t.Errorf("expected ErrIDTokenUnavailable, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "id-token: write") {
+		t.Errorf("expected the error to mention the permission, got %q", err)
+	}
+}
+
+func TestGetIDTokenEmptyValue(t *testing.T) {
+	setup(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+
+	_, err := oidcutils.GetIDToken(context.Background(), nil)
+	if err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestGetIDTokenCanceled(t *testing.T) {
+	setup(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "try again", http.StatusBadGateway)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := oidcutils.GetIDToken(ctx, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package oidcutils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultExpiryDelta is how long before it expires a cached token is
+// refreshed, so it doesn't expire while a request using it is in flight.
+const DefaultExpiryDelta = time.Minute
+
+// Token has the shape of golang.org/x/oauth2.Token.
+type Token struct {
+	AccessToken string
+	// Always "Bearer" for ID tokens.
+	TokenType string
+	// Zero if the expiry is unknown because the token has no readable exp
+	// claim.
+	Expiry time.Time
+}
+
+// TokenCache caches ID tokens per audience. It is safe for concurrent use;
+// concurrent requests for the same audience share a single fetch.
+type TokenCache struct {
+	// Fetch gets a new ID token.
+	Fetch func(ctx context.Context, audience *string) (string, error)
+	// Now defaults to time.Now.
+	Now func() time.Time
+	// ExpiryDelta defaults to DefaultExpiryDelta.
+	ExpiryDelta *time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*tokenCacheEntry
+}
+
+type tokenCacheEntry struct {
+	mu    sync.Mutex
+	token *Token
+}
+
+func (c *TokenCache) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+func (c *TokenCache) valid(t *Token) bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	if t.Expiry.IsZero() {
+		return false
+	}
+	delta := DefaultExpiryDelta
+	if c.ExpiryDelta != nil {
+		delta = *c.ExpiryDelta
+	}
+	return c.now().Add(delta).Before(t.Expiry)
+}
+
+// Token returns the cached token for audience, fetching a new one if there
+// is none or it is about to expire.
+func (c *TokenCache) Token(ctx context.Context, audience *string) (*Token, error) {
+	key := "\x00default"
+	if audience != nil {
+		key = *audience
+	}
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = map[string]*tokenCacheEntry{}
+	}
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &tokenCacheEntry{}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if c.valid(entry.token) {
+		return copyToken(entry.token), nil
+	}
+	idToken, err := c.Fetch(ctx, audience)
+	if err != nil {
+		return nil, err
+	}
+	token := &Token{AccessToken: idToken, TokenType: "Bearer"}
+	if claims, err := ParseClaims(idToken); err == nil {
+		token.Expiry = claims.ExpiresAt.Time
+	}
+	// A token without an expiry could be stale any time, so it isn't
+	// cached.
+	entry.token = nil
+	if !token.Expiry.IsZero() {
+		entry.token = token
+	}
+	return copyToken(token), nil
+}
+
+func copyToken(t *Token) *Token {
+	c := *t
+	return &c
+}
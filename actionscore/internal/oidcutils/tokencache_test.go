@@ -0,0 +1,139 @@
+package oidcutils_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/oidcutils"
+)
+
+// unsignedJWT is enough for the cache, which only reads exp.
+func unsignedJWT(t *testing.T, audience string, exp time.Time) string {
+	payload, err := json.Marshal(map[string]any{"aud": audience, "exp": exp.Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString(payload) + ".c2ln"
+}
+
+func TestTokenCache(t *testing.T) {
+	clock := now
+	var fetches atomic.Int32
+	cache := &oidcutils.TokenCache{
+		Fetch: func(ctx context.Context, audience *string) (string, error) {
+			fetches.Add(1)
+			aud := "default"
+			if audience != nil {
+				aud = *audience
+			}
+			return unsignedJWT(t, aud, clock.Add(5*time.Minute)), nil
+		},
+		Now: func() time.Time { return clock },
+	}
+	ctx := context.Background()
+
+	first, err := cache.Token(ctx, ptr("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.TokenType != "Bearer" || !first.Expiry.Equal(now.Add(5*time.Minute)) {
+		t.Errorf("unexpected token %+v", first)
+	}
+	second, _ := cache.Token(ctx, ptr("a"))
+	if second.AccessToken != first.AccessToken || fetches.Load() != 1 {
+		t.Errorf("expected the cached token, got %d fetches", fetches.Load())
+	}
+
+	_, _ = cache.Token(ctx, ptr("b"))
+	_, _ = cache.Token(ctx, nil)
+	if fetches.Load() != 3 {
+		t.Errorf("expected a fetch per audience, got %d fetches", fetches.Load())
+	}
+
+	// Within a minute of exp the token is refreshed.
+	clock = now.Add(4*time.Minute + 30*time.Second)
+	refreshed, _ := cache.Token(ctx, ptr("a"))
+	if refreshed.AccessToken == first.AccessToken || fetches.Load() != 4 {
+		t.Errorf("expected a refreshed token, got %d fetches", fetches.Load())
+	}
+}
+
+func TestTokenCacheConcurrent(t *testing.T) {
+	var fetches atomic.Int32
+	cache := &oidcutils.TokenCache{
+		Fetch: func(ctx context.Context, audience *string) (string, error) {
+			fetches.Add(1)
+			time.Sleep(10 * time.Millisecond)
+			return unsignedJWT(t, *audience, time.Now().Add(time.Hour)), nil
+		},
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.Token(context.Background(), ptr("aud"))
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if fetches.Load() != 1 {
+		t.Errorf("expected 1 fetch, got %d", fetches.Load())
+	}
+}
+
+func TestTokenCacheError(t *testing.T) {
+	fail := true
+	cache := &oidcutils.TokenCache{
+		Fetch: func(ctx context.Context, audience *string) (string, error) {
+			if fail {
+				return "", oidcutils.ErrIDTokenUnavailable
+			}
+			return unsignedJWT(t, "x", time.Now().Add(time.Hour)), nil
+		},
+	}
+	_, err := cache.Token(context.Background(), nil)
+	if !errors.Is(err, oidcutils.ErrIDTokenUnavailable) {
+		t.Errorf("expected ErrIDTokenUnavailable, got %v", err)
+	}
+	fail = false
+	_, err = cache.Token(context.Background(), nil)
+	if err != nil {
+		t.Errorf("expected the error not to be cached, got %v", err)
+	}
+}
+
+func TestTokenCacheNoExpiry(t *testing.T) {
+	tokens := []string{
+		"eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(`{"aud":"x"}`)) + ".c2ln",
+		"not-a-jwt",
+	}
+	for _, idToken := range tokens {
+		var fetches atomic.Int32
+		cache := &oidcutils.TokenCache{
+			Fetch: func(ctx context.Context, audience *string) (string, error) {
+				fetches.Add(1)
+				return idToken, nil
+			},
+		}
+		token, err := cache.Token(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.AccessToken != idToken || !token.Expiry.IsZero() {
+			t.Errorf("%s: unexpected token %+v", idToken, token)
+		}
+		_, _ = cache.Token(context.Background(), nil)
+		if fetches.Load() != 2 {
+			t.Errorf("%s: expected a token without an expiry not to be cached, got %d fetches", idToken, fetches.Load())
+		}
+	}
+}
@@ -1,18 +1,206 @@
 package pathutils
 
 import (
-	"path/filepath"
+	"path"
+	"runtime"
 	"strings"
 )
 
+// ContainerWorkspace is where the runner mounts GITHUB_WORKSPACE inside
+// Docker container actions.
+const ContainerWorkspace = "/github/workspace"
+
+// ToPosixPath converts p to forward slashes. Drive letters are kept
+// (C:/foo), UNC paths become //server/share/foo, \\?\ prefixes are dropped
+// and runs of separators are collapsed.
 func ToPosixPath(p string) string {
-	return strings.ReplaceAll(p, "\\", "/")
+	return toPath(p, '/')
 }
 
+// ToWin32Path converts p to backslashes, like ToPosixPath does the other way
+// round. UNC paths stay \\server\share\foo.
 func ToWin32Path(p string) string {
-	return strings.ReplaceAll(p, "/", "\\")
+	return toPath(p, '\\')
 }
 
+// ToPlatformPath converts p to the separators of the current OS.
 func ToPlatformPath(p string) string {
-	return strings.NewReplacer("/", string(filepath.Separator), "\\", string(filepath.Separator)).Replace(p)
+	return ToGOOSPath(runtime.GOOS, p)
+}
+
+// ToGOOSPath converts p to the separators of goos.
+func ToGOOSPath(goos string, p string) string {
+	if goos == "windows" {
+		return ToWin32Path(p)
+	}
+	return ToPosixPath(p)
+}
+
+// IsAbs reports whether p is absolute on goos. On Windows that means it has
+// a drive letter and a root, or is a UNC path.
+func IsAbs(goos string, p string) bool {
+	if goos != "windows" {
+		return strings.HasPrefix(p, "/")
+	}
+	volume, rest := splitVolume(p)
+	if isUNC(volume) {
+		return true
+	}
+	return volume != "" && rest != "" && isSeparator(rest[0])
+}
+
+// RelativeToWorkspace returns p relative to workspace with forward slashes,
+// the form annotations expect for their file property. Relative paths are
+// taken to already be relative to the workspace. ok is false if p is outside
+// the workspace, or if workspace is empty. Paths on Windows are compared
+// case-insensitively.
+func RelativeToWorkspace(goos string, workspace string, p string) (rel string, ok bool) {
+	if workspace == "" {
+		return "", false
+	}
+	if !IsAbs(goos, p) {
+		rel = path.Clean(ToPosixPath(p))
+		if rel == ".." || strings.HasPrefix(rel, "../") || strings.HasPrefix(rel, "/") {
+			return "", false
+		}
+		return rel, true
+	}
+	return trimBase(goos, cleanPosix(workspace), cleanPosix(p))
+}
+
+// ToContainerPath translates a host path under workspace to where it appears
+// inside a Docker container action, under ContainerWorkspace. ok is false if
+// p is outside the workspace or if workspace is empty.
+func ToContainerPath(goos string, workspace string, p string) (string, bool) {
+	rel, ok := RelativeToWorkspace(goos, workspace, p)
+	if !ok {
+		return "", false
+	}
+	if rel == "." {
+		return ContainerWorkspace, true
+	}
+	return ContainerWorkspace + "/" + rel, true
+}
+
+// FromContainerPath translates a path inside a Docker container action back
+// to the host path on goos. ok is false if p is outside ContainerWorkspace
+// or if workspace is empty.
+func FromContainerPath(goos string, workspace string, p string) (string, bool) {
+	if workspace == "" {
+		return "", false
+	}
+	rel, ok := trimBase("linux", ContainerWorkspace, cleanPosix(p))
+	if !ok {
+		return "", false
+	}
+	host := cleanPosix(workspace)
+	if rel != "." {
+		host = strings.TrimSuffix(host, "/") + "/" + rel
+	}
+	return ToGOOSPath(goos, host), true
+}
+
+// trimBase returns target relative to base, both cleaned posix paths.
+func trimBase(goos string, base string, target string) (string, bool) {
+	hasPrefix := strings.HasPrefix
+	equal := func(a, b string) bool { return a == b }
+	if goos == "windows" {
+		hasPrefix = func(s, prefix string) bool {
+			return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+		}
+		equal = strings.EqualFold
+	}
+	if equal(target, base) {
+		return ".", true
+	}
+	prefix := strings.TrimSuffix(base, "/") + "/"
+	if !hasPrefix(target, prefix) {
+		return "", false
+	}
+	return target[len(prefix):], true
+}
+
+// cleanPosix converts p to forward slashes and resolves . and .. elements,
+// keeping the volume.
+func cleanPosix(p string) string {
+	volume, rest := splitVolume(ToPosixPath(p))
+	volume = ToPosixPath(volume)
+	if rest == "" {
+		return volume
+	}
+	return volume + path.Clean(rest)
+}
+
+func toPath(p string, sep byte) string {
+	volume, rest := splitVolume(p)
+	var b strings.Builder
+	if isUNC(volume) {
+		b.WriteByte(sep)
+		b.WriteByte(sep)
+		server, share, _ := strings.Cut(volume[2:], `\`)
+		b.WriteString(server)
+		b.WriteByte(sep)
+		b.WriteString(share)
+	} else {
+		b.WriteString(volume)
+	}
+	lastSep := false
+	for i := 0; i < len(rest); i++ {
+		if isSeparator(rest[i]) {
+			if !lastSep {
+				b.WriteByte(sep)
+			}
+			lastSep = true
+			continue
+		}
+		b.WriteByte(rest[i])
+		lastSep = false
+	}
+	return b.String()
+}
+
+// splitVolume splits p into a Windows volume (C: or a UNC \\server\share)
+// and the rest, dropping any \\?\ or \\.\ prefix. UNC volumes are always
+// returned with backslashes.
+func splitVolume(p string) (volume string, rest string) {
+	if len(p) >= 4 && isSeparator(p[0]) && isSeparator(p[1]) && (p[2] == '?' || p[2] == '.') && isSeparator(p[3]) {
+		p = p[4:]
+		if len(p) >= 4 && strings.EqualFold(p[:3], "UNC") && isSeparator(p[3]) {
+			p = `\\` + p[4:]
+		}
+	}
+	if len(p) >= 2 && isLetter(p[0]) && p[1] == ':' {
+		return p[:2], p[2:]
+	}
+	if len(p) >= 3 && isSeparator(p[0]) && isSeparator(p[1]) && !isSeparator(p[2]) {
+		// \\server\share
+		i := 2
+		for i < len(p) && !isSeparator(p[i]) {
+			i++
+		}
+		j := i
+		for j < len(p) && isSeparator(p[j]) {
+			j++
+		}
+		k := j
+		for k < len(p) && !isSeparator(p[k]) {
+			k++
+		}
+		if k > j {
+			return `\\` + p[2:i] + `\` + p[j:k], p[k:]
+		}
+	}
+	return "", p
+}
+
+func isUNC(volume string) bool {
+	return len(volume) > 2 && isSeparator(volume[0]) && isSeparator(volume[1])
+}
+
+func isSeparator(c byte) bool {
+	return c == '/' || c == '\\'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
 }
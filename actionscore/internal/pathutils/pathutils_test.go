@@ -0,0 +1,145 @@
+package pathutils_test
+
+import (
+	"testing"
+
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/pathutils"
+)
+
+func TestToPosixPath(t *testing.T) {
+	tests := map[string]string{
+		"":                            "",
+		"foo/bar":                     "foo/bar",
+		`foo\bar`:                     "foo/bar",
+		`C:\Users\runner\\work`:       "C:/Users/runner/work",
+		`c:relative\path`:             "c:relative/path",
+		`\\server\share\dir\file.txt`: "//server/share/dir/file.txt",
+		`\\?\C:\very\long\path`:       "C:/very/long/path",
+		`\\?\UNC\server\share\file`:   "//server/share/file",
+		`\\.\D:\device`:               "D:/device",
+		"/usr//local///bin/":          "/usr/local/bin/",
+		"//server/share//x":           "//server/share/x",
+	}
+	for input, expected := range tests {
+		if got := pathutils.ToPosixPath(input); got != expected {
+			t.Errorf("ToPosixPath(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+}
+
+func TestToWin32Path(t *testing.T) {
+	tests := map[string]string{
+		"foo/bar":                   `foo\bar`,
+		"C:/Users//runner/":         `C:\Users\runner\`,
+		"//server/share/dir":        `\\server\share\dir`,
+		`\\?\UNC\server\share\file`: `\\server\share\file`,
+		`\\?\C:\a//b`:               `C:\a\b`,
+	}
+	for input, expected := range tests {
+		if got := pathutils.ToWin32Path(input); got != expected {
+			t.Errorf("ToWin32Path(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+}
+
+func TestToGOOSPath(t *testing.T) {
+	if got := pathutils.ToGOOSPath("windows", "a/b"); got != `a\b` {
+		t.Errorf("expected %q, got %q", `a\b`, got)
+	}
+	if got := pathutils.ToGOOSPath("linux", `a\b`); got != "a/b" {
+		t.Errorf("expected %q, got %q", "a/b", got)
+	}
+}
+
+func TestIsAbs(t *testing.T) {
+	tests := []struct {
+		goos     string
+		path     string
+		expected bool
+	}{
+		{"linux", "/home/runner", true},
+		{"linux", "home/runner", false},
+		{"windows", `C:\a`, true},
+		{"windows", "C:/a", true},
+		{"windows", "C:a", false},
+		{"windows", `\a`, false},
+		{"windows", `\\server\share`, true},
+		{"windows", `\\?\C:\a`, true},
+		{"windows", "a", false},
+	}
+	for _, tt := range tests {
+		if got := pathutils.IsAbs(tt.goos, tt.path); got != tt.expected {
+			t.Errorf("IsAbs(%q, %q): expected %v, got %v", tt.goos, tt.path, tt.expected, got)
+		}
+	}
+}
+
+func TestRelativeToWorkspace(t *testing.T) {
+	tests := []struct {
+		goos      string
+		workspace string
+		path      string
+		expected  string
+		ok        bool
+	}{
+		{"linux", "/home/runner/work/repo/repo", "/home/runner/work/repo/repo/src/main.go", "src/main.go", true},
+		{"linux", "/home/runner/work/repo/repo/", "/home/runner/work/repo/repo", ".", true},
+		{"linux", "/home/runner/work/repo/repo", "/home/runner/work/repo/repo/a/../b.go", "b.go", true},
+		{"linux", "/home/runner/work/repo/repo", "/home/runner/work/repo/repo2/x", "", false},
+		{"linux", "/home/runner/work/repo/repo", "/home/runner/work/repo/Repo/x", "", false},
+		{"linux", "/w", "./src//x.go", "src/x.go", true},
+		{"linux", "/w", "../x.go", "", false},
+		{"windows", `D:\a\repo\repo`, `D:\a\repo\repo\src\main.go`, "src/main.go", true},
+		{"windows", `D:\a\repo\repo`, `d:\A\Repo\repo\src\main.go`, "src/main.go", true},
+		{"windows", `D:\a\repo\repo`, `\\?\D:\a\repo\repo\x.go`, "x.go", true},
+		{"windows", `D:\a\repo\repo`, `C:\a\repo\repo\x.go`, "", false},
+		{"windows", `\\server\share\repo`, `//server/share/repo/x.go`, "x.go", true},
+		{"windows", `D:\a\repo\repo`, `src\x.go`, "src/x.go", true},
+		{"linux", "", "/src/main.go", "", false},
+		{"linux", "", "src/main.go", "", false},
+		{"windows", "", `D:\src\main.go`, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := pathutils.RelativeToWorkspace(tt.goos, tt.workspace, tt.path)
+		if got != tt.expected || ok != tt.ok {
+			t.Errorf("RelativeToWorkspace(%q, %q, %q): expected %q %v, got %q %v", tt.goos, tt.workspace, tt.path, tt.expected, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestContainerPath(t *testing.T) {
+	workspace := "/home/runner/work/repo/repo"
+	got, ok := pathutils.ToContainerPath("linux", workspace, workspace+"/dist/out.txt")
+	if !ok || got != "/github/workspace/dist/out.txt" {
+		t.Errorf("expected %q, got %q %v", "/github/workspace/dist/out.txt", got, ok)
+	}
+	got, ok = pathutils.ToContainerPath("linux", workspace, workspace)
+	if !ok || got != "/github/workspace" {
+		t.Errorf("expected %q, got %q %v", "/github/workspace", got, ok)
+	}
+	_, ok = pathutils.ToContainerPath("linux", workspace, "/tmp/x")
+	if ok {
+		t.Errorf("expected a path outside the workspace to fail")
+	}
+	_, ok = pathutils.ToContainerPath("linux", "", "/tmp/x")
+	if ok {
+		t.Errorf("expected an empty workspace to fail")
+	}
+
+	got, ok = pathutils.FromContainerPath("linux", workspace, "/github/workspace/dist/out.txt")
+	if !ok || got != workspace+"/dist/out.txt" {
+		t.Errorf("expected %q, got %q %v", workspace+"/dist/out.txt", got, ok)
+	}
+	got, ok = pathutils.FromContainerPath("windows", `D:\a\repo\repo`, "/github/workspace/dist/out.txt")
+	if !ok || got != `D:\a\repo\repo\dist\out.txt` {
+		t.Errorf("expected %q, got %q %v", `D:\a\repo\repo\dist\out.txt`, got, ok)
+	}
+	_, ok = pathutils.FromContainerPath("linux", workspace, "/github/home/x")
+	if ok {
+		t.Errorf("expected a path outside the container workspace to fail")
+	}
+	_, ok = pathutils.FromContainerPath("linux", "", "/github/workspace/dist/out.txt")
+	if ok {
+		t.Errorf("expected an empty workspace to fail")
+	}
+}
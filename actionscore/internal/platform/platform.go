@@ -1,118 +1,23 @@
 package platform
 
 import (
+	"errors"
+	"fmt"
+	"os"
 	"os/exec"
 	"regexp"
 	"runtime"
 	"strings"
+	"sync"
 )
 
-func getWindowsInfo() (struct {
-	Name    string
-	Version string
-}, error) {
-	cmd := exec.Command("powershell", "-command", "(Get-CimInstance -ClassName Win32_OperatingSystem).Version")
-	version, err := cmd.Output()
-	if err != nil {
-		return struct {
-			Name    string
-			Version string
-		}{}, err
-	}
-
-	cmd = exec.Command("powershell", "-command", "(Get-CimInstance -ClassName Win32_OperatingSystem).Caption")
-	name, err := cmd.Output()
-	if err != nil {
-		return struct {
-			Name    string
-			Version string
-		}{}, err
-	}
-
-	return struct {
-		Name    string
-		Version string
-	}{
-		Version: strings.TrimSpace(string(version)),
-		Name:    strings.TrimSpace(string(name)),
-	}, nil
-}
-
-func getMacOSInfo() (struct {
-	Name    string
-	Version string
-}, error) {
-	cmd := exec.Command("sw_vers")
-	out, err := cmd.Output()
-	if err != nil {
-		return struct {
-			Name    string
-			Version string
-		}{}, err
-	}
-	version := func() string {
-		versionMatch := regexp.MustCompile(`ProductVersion:\s*(.+)`).FindStringSubmatch(string(out))
-		if len(versionMatch) == 0 {
-			return ""
-		}
-		return versionMatch[1]
-	}()
-	name := func() string {
-		nameMatch := regexp.MustCompile(`ProductName:\s*(.+)`).FindStringSubmatch(string(out))
-		if len(nameMatch) == 0 {
-			return ""
-		}
-		return nameMatch[1]
-	}()
-
-	return struct {
-		Name    string
-		Version string
-	}{
-		Name:    name,
-		Version: version,
-	}, nil
-}
-
-func getLinuxInfo() (struct {
-	Name    string
-	Version string
-}, error) {
-	cmd := exec.Command("lsb_release", "-i", "-r", "-s")
-	out, err := cmd.Output()
-	if err != nil {
-		return struct {
-			Name    string
-			Version string
-		}{}, err
-	}
-
-	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
-	if len(lines) < 2 {
-		return struct {
-			Name    string
-			Version string
-		}{}, nil
-	}
-	name := strings.TrimSpace(lines[0])
-	version := strings.TrimSpace(lines[1])
-
-	return struct {
-		Name    string
-		Version string
-	}{
-		Name:    name,
-		Version: version,
-	}, nil
-}
-
 const Platform = runtime.GOOS
 const Arch = runtime.GOARCH
 const IsWindows = Platform == "windows"
 const IsMacOS = Platform == "darwin"
 const IsLinux = Platform == "linux"
 
-func GetDetails() (struct {
+type Details struct {
 	Name      string
 	Platform  string
 	Arch      string
@@ -120,67 +25,201 @@ func GetDetails() (struct {
 	IsWindows bool
 	IsMacOS   bool
 	IsLinux   bool
-}, error) {
+
+	// The rest are only set on Linux, from /etc/os-release.
+	ID              string
+	IDLike          []string
+	VersionID       string
+	VersionCodename string
+}
+
+// Detector looks up the details of the OS and the runner. The zero value
+// inspects the current machine; tests can set any field to fake another one.
+// Details are memoized, so a Detector must not be modified after its first
+// use.
+type Detector struct {
+	GOOS   string
+	GOARCH string
+	// Command runs a program and returns its stdout. Defaults to
+	// exec.Command(name, args...).Output().
+	Command func(name string, args ...string) ([]byte, error)
+	// ReadFile defaults to os.ReadFile.
+	ReadFile func(name string) ([]byte, error)
+	// Getenv defaults to os.Getenv.
+	Getenv func(key string) string
+	// NumCPU defaults to runtime.NumCPU.
+	NumCPU func() int
+	// FreeDisk returns the bytes available to the user on the file system
+	// holding path.
+	FreeDisk func(path string) (uint64, error)
+
+	once    sync.Once
+	details Details
+	err     error
+}
+
+var defaultDetector Detector
+
+// GetDetails returns the details of the current machine. It is memoized.
+func GetDetails() (Details, error) {
+	return defaultDetector.Details()
+}
+
+func (d *Detector) Details() (Details, error) {
+	d.once.Do(func() {
+		d.details, d.err = d.detect()
+	})
+	return d.details, d.err
+}
+
+func (d *Detector) detect() (Details, error) {
+	details := Details{Platform: d.GOOS, Arch: d.GOARCH}
+	if details.Platform == "" {
+		details.Platform = Platform
+	}
+	if details.Arch == "" {
+		details.Arch = Arch
+	}
+	details.IsWindows = details.Platform == "windows"
+	details.IsMacOS = details.Platform == "darwin"
+	details.IsLinux = details.Platform == "linux"
+
 	var err error
-	var info struct {
-		Name    string
-		Version string
+	if details.IsWindows {
+		err = d.windowsInfo(&details)
+	} else if details.IsMacOS {
+		err = d.macOSInfo(&details)
+	} else {
+		err = d.linuxInfo(&details)
+	}
+	if err != nil {
+		return Details{}, err
+	}
+	return details, nil
+}
+
+func (d *Detector) command(name string, args ...string) ([]byte, error) {
+	if d.Command != nil {
+		return d.Command(name, args...)
 	}
-	if IsWindows {
-		info, err = getWindowsInfo()
+	return exec.Command(name, args...).Output()
+}
+
+func (d *Detector) readFile(name string) ([]byte, error) {
+	if d.ReadFile != nil {
+		return d.ReadFile(name)
+	}
+	return os.ReadFile(name)
+}
+
+func (d *Detector) windowsInfo(details *Details) error {
+	version, err := d.command("powershell", "-command", "(Get-CimInstance -ClassName Win32_OperatingSystem).Version")
+	if err != nil {
+		return err
+	}
+	name, err := d.command("powershell", "-command", "(Get-CimInstance -ClassName Win32_OperatingSystem).Caption")
+	if err != nil {
+		return err
+	}
+	details.Version = strings.TrimSpace(string(version))
+	details.Name = strings.TrimSpace(string(name))
+	return nil
+}
+
+var (
+	productVersionRegExp = regexp.MustCompile(`ProductVersion:\s*(.+)`)
+	productNameRegExp    = regexp.MustCompile(`ProductName:\s*(.+)`)
+)
+
+func (d *Detector) macOSInfo(details *Details) error {
+	out, err := d.command("sw_vers")
+	if err != nil {
+		return err
+	}
+	if m := productVersionRegExp.FindStringSubmatch(string(out)); m != nil {
+		details.Version = strings.TrimSpace(m[1])
+	}
+	if m := productNameRegExp.FindStringSubmatch(string(out)); m != nil {
+		details.Name = strings.TrimSpace(m[1])
+	}
+	return nil
+}
+
+// linuxInfo reads os-release(5), falling back to lsb_release for the rare
+// system without one.
+func (d *Detector) linuxInfo(details *Details) error {
+	var readErr error
+	for _, name := range []string{"/etc/os-release", "/usr/lib/os-release"} {
+		content, err := d.readFile(name)
 		if err != nil {
-			return struct {
-				Name      string
-				Platform  string
-				Arch      string
-				Version   string
-				IsWindows bool
-				IsMacOS   bool
-				IsLinux   bool
-			}{}, err
+			readErr = errors.Join(readErr, err)
+			continue
 		}
-	} else if IsMacOS {
-		info, err = getMacOSInfo()
-		if err != nil {
-			return struct {
-				Name      string
-				Platform  string
-				Arch      string
-				Version   string
-				IsWindows bool
-				IsMacOS   bool
-				IsLinux   bool
-			}{}, err
+		fields := ParseOSRelease(string(content))
+		details.Name = fields["NAME"]
+		if details.Name == "" {
+			details.Name = "Linux"
 		}
-	} else {
-		info, err = getLinuxInfo()
-		if err != nil {
-			return struct {
-				Name      string
-				Platform  string
-				Arch      string
-				Version   string
-				IsWindows bool
-				IsMacOS   bool
-				IsLinux   bool
-			}{}, err
+		details.Version = fields["VERSION_ID"]
+		details.ID = fields["ID"]
+		if details.ID == "" {
+			details.ID = "linux"
+		}
+		details.IDLike = strings.Fields(fields["ID_LIKE"])
+		details.VersionID = fields["VERSION_ID"]
+		details.VersionCodename = fields["VERSION_CODENAME"]
+		return nil
+	}
+
+	out, err := d.command("lsb_release", "-i", "-r", "-s")
+	if err != nil {
+		return fmt.Errorf("no os-release file and lsb_release failed: %w", errors.Join(readErr, err))
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) < 2 {
+		return nil
+	}
+	details.Name = strings.TrimSpace(lines[0])
+	details.Version = strings.TrimSpace(lines[1])
+	return nil
+}
+
+// ParseOSRelease parses the KEY=value lines of an os-release file, removing
+// shell quoting from the values.
+func ParseOSRelease(content string) map[string]string {
+	fields := map[string]string{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		fields[key] = unquote(value)
+	}
+	return fields
+}
+
+func unquote(value string) string {
+	if len(value) < 2 {
+		return value
+	}
+	switch quote := value[0]; {
+	case quote == '\'' && value[len(value)-1] == '\'':
+		return value[1 : len(value)-1]
+	case quote == '"' && value[len(value)-1] == '"':
+		var b strings.Builder
+		inner := value[1 : len(value)-1]
+		for i := 0; i < len(inner); i++ {
+			if inner[i] == '\\' && i+1 < len(inner) && strings.IndexByte("\"\\$`", inner[i+1]) >= 0 {
+				i++
+			}
+			b.WriteByte(inner[i])
 		}
+		return b.String()
+	default:
+		return value
 	}
-	return struct {
-		Name      string
-		Platform  string
-		Arch      string
-		Version   string
-		IsWindows bool
-		IsMacOS   bool
-		IsLinux   bool
-	}{
-		Name:      info.Name,
-		Platform:  Platform,
-		Arch:      Arch,
-		Version:   info.Version,
-		IsWindows: IsWindows,
-		IsMacOS:   IsMacOS,
-		IsLinux:   IsLinux,
-	}, nil
 }
@@ -0,0 +1,278 @@
+package platform_test
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/platform"
+)
+
+const ubuntuOSRelease = `PRETTY_NAME="Ubuntu 24.04.1 LTS"
+NAME="Ubuntu"
+VERSION_ID="24.04"
+VERSION="24.04.1 LTS (Noble Numbat)"
+VERSION_CODENAME=noble
+ID=ubuntu
+ID_LIKE=debian
+HOME_URL="https://www.ubuntu.com/"
+`
+
+func fakeCommand(outputs map[string]string) func(name string, args ...string) ([]byte, error) {
+	return func(name string, args ...string) ([]byte, error) {
+		line := strings.Join(append([]string{name}, args...), " ")
+		out, ok := outputs[line]
+		if !ok {
+			return nil, &fs.PathError{Op: "exec", Path: name, Err: fs.ErrNotExist}
+		}
+		return []byte(out), nil
+	}
+}
+
+func fakeReadFile(files map[string]string) func(name string) ([]byte, error) {
+	return func(name string) ([]byte, error) {
+		content, ok := files[name]
+		if !ok {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		return []byte(content), nil
+	}
+}
+
+func TestDetailsLinuxOSRelease(t *testing.T) {
+	d := &platform.Detector{
+		GOOS:     "linux",
+		GOARCH:   "amd64",
+		Command:  fakeCommand(nil),
+		ReadFile: fakeReadFile(map[string]string{"/etc/os-release": ubuntuOSRelease}),
+	}
+	got, err := d.Details()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := platform.Details{
+		Name:            "Ubuntu",
+		Platform:        "linux",
+		Arch:            "amd64",
+		Version:         "24.04",
+		IsLinux:         true,
+		ID:              "ubuntu",
+		IDLike:          []string{"debian"},
+		VersionID:       "24.04",
+		VersionCodename: "noble",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestDetailsLinuxUsrLibOSRelease(t *testing.T) {
+	d := &platform.Detector{
+		GOOS:     "linux",
+		Command:  fakeCommand(nil),
+		ReadFile: fakeReadFile(map[string]string{"/usr/lib/os-release": "ID=alpine\nVERSION_ID=3.20.3\nNAME=\"Alpine Linux\"\n"}),
+	}
+	got, err := d.Details()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Alpine Linux" || got.ID != "alpine" || got.Version != "3.20.3" || len(got.IDLike) != 0 {
+		t.Errorf("unexpected details %+v", got)
+	}
+}
+
+func TestDetailsLinuxLSBRelease(t *testing.T) {
+	d := &platform.Detector{
+		GOOS:     "linux",
+		Command:  fakeCommand(map[string]string{"lsb_release -i -r -s": "Debian\n12\n"}),
+		ReadFile: fakeReadFile(nil),
+	}
+	got, err := d.Details()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Debian" || got.Version != "12" {
+		t.Errorf("expected Debian 12, got %q %q", got.Name, got.Version)
+	}
+}
+
+func TestDetailsLinuxNothing(t *testing.T) {
+	d := &platform.Detector{
+		GOOS:     "linux",
+		Command:  fakeCommand(nil),
+		ReadFile: fakeReadFile(nil),
+	}
+	_, err := d.Details()
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestDetailsMacOS(t *testing.T) {
+	d := &platform.Detector{
+		GOOS:   "darwin",
+		GOARCH: "arm64",
+		Command: fakeCommand(map[string]string{
+			"sw_vers": "ProductName:\t\tmacOS\nProductVersion:\t\t14.6.1\nBuildVersion:\t\t23G93\n",
+		}),
+	}
+	got, err := d.Details()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "macOS" || got.Version != "14.6.1" || !got.IsMacOS || got.IsLinux {
+		t.Errorf("unexpected details %+v", got)
+	}
+}
+
+func TestDetailsWindows(t *testing.T) {
+	d := &platform.Detector{
+		GOOS: "windows",
+		Command: fakeCommand(map[string]string{
+			"powershell -command (Get-CimInstance -ClassName Win32_OperatingSystem).Version": "10.0.20348\r\n",
+			"powershell -command (Get-CimInstance -ClassName Win32_OperatingSystem).Caption": "Microsoft Windows Server 2022 Datacenter\r\n",
+		}),
+	}
+	got, err := d.Details()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Microsoft Windows Server 2022 Datacenter" || got.Version != "10.0.20348" || !got.IsWindows {
+		t.Errorf("unexpected details %+v", got)
+	}
+}
+
+func TestDetailsMemoized(t *testing.T) {
+	calls := 0
+	d := &platform.Detector{
+		GOOS: "darwin",
+		Command: func(name string, args ...string) ([]byte, error) {
+			calls++
+			return []byte("ProductName: macOS\nProductVersion: 15.0\n"), nil
+		},
+	}
+	for i := 0; i < 3; i++ {
+		_, err := d.Details()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestParseOSRelease(t *testing.T) {
+	got := platform.ParseOSRelease("# comment\nA=plain\nB=\"double \\\"quoted\\\" \\$x\"\nC='single'\n\nbogus\n")
+	expected := map[string]string{"A": "plain", "B": `double "quoted" $x`, "C": "single"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestRunner(t *testing.T) {
+	env := map[string]string{
+		"RUNNER_OS":          "Linux",
+		"RUNNER_ARCH":        "X64",
+		"RUNNER_NAME":        "GitHub Actions 2",
+		"RUNNER_TEMP":        "/home/runner/work/_temp",
+		"RUNNER_TOOL_CACHE":  "/opt/hostedtoolcache",
+		"RUNNER_ENVIRONMENT": "github-hosted",
+		"ImageOS":            "ubuntu24",
+		"ImageVersion":       "20241015.1.0",
+	}
+	var freeDiskPath string
+	d := &platform.Detector{
+		GOOS:   "linux",
+		Getenv: func(key string) string { return env[key] },
+		NumCPU: func() int { return 4 },
+		FreeDisk: func(path string) (uint64, error) {
+			freeDiskPath = path
+			return 14 << 30, nil
+		},
+		ReadFile: fakeReadFile(map[string]string{
+			"/proc/meminfo":  "MemTotal:       16365268 kB\nMemFree:         1000000 kB\n",
+			"/proc/1/cgroup": "0::/init.scope\n",
+		}),
+	}
+	got := d.Runner()
+	expected := platform.Runner{
+		OS:           "Linux",
+		Arch:         "X64",
+		Name:         "GitHub Actions 2",
+		Temp:         "/home/runner/work/_temp",
+		ToolCache:    "/opt/hostedtoolcache",
+		Environment:  "github-hosted",
+		GitHubHosted: true,
+		ImageOS:      "ubuntu24",
+		ImageVersion: "20241015.1.0",
+		CPUs:         4,
+		Memory:       16365268 * 1024,
+		FreeDisk:     14 << 30,
+	}
+	if got != expected {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+	if freeDiskPath != env["RUNNER_TEMP"] {
+		t.Errorf("expected free disk space of %q, got %q", env["RUNNER_TEMP"], freeDiskPath)
+	}
+}
+
+func TestRunnerContainer(t *testing.T) {
+	tests := []struct {
+		files    map[string]string
+		expected bool
+	}{
+		{map[string]string{"/.dockerenv": ""}, true},
+		{map[string]string{"/run/.containerenv": ""}, true},
+		{map[string]string{"/proc/1/cgroup": "12:memory:/kubepods/burstable/pod1234\n"}, true},
+		{map[string]string{"/proc/1/cgroup": "0::/init.scope\n"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		d := &platform.Detector{
+			GOOS:     "linux",
+			Getenv:   func(string) string { return "" },
+			FreeDisk: func(string) (uint64, error) { return 0, nil },
+			ReadFile: fakeReadFile(tt.files),
+		}
+		if got := d.Runner().Container; got != tt.expected {
+			t.Errorf("%v: expected %v, got %v", tt.files, tt.expected, got)
+		}
+	}
+}
+
+func TestRunnerMemory(t *testing.T) {
+	tests := []struct {
+		goos    string
+		command string
+	}{
+		{"darwin", "sysctl -n hw.memsize"},
+		{"windows", "powershell -command (Get-CimInstance -ClassName Win32_ComputerSystem).TotalPhysicalMemory"},
+	}
+	for _, tt := range tests {
+		d := &platform.Detector{
+			GOOS:     tt.goos,
+			Getenv:   func(string) string { return "" },
+			FreeDisk: func(string) (uint64, error) { return 0, nil },
+			Command:  fakeCommand(map[string]string{tt.command: "17179869184\n"}),
+		}
+		if got := d.Runner().Memory; got != 17179869184 {
+			t.Errorf("%s: expected 17179869184, got %d", tt.goos, got)
+		}
+	}
+}
+
+func TestRunnerFreeDisk(t *testing.T) {
+	d := &platform.Detector{Getenv: func(key string) string {
+		if key == "RUNNER_TEMP" {
+			return t.TempDir()
+		}
+		return ""
+	}}
+	if got := d.Runner(); got.FreeDisk == 0 && (platform.IsLinux || platform.IsMacOS || platform.IsWindows) {
+		t.Errorf("expected free disk space, got %+v", got)
+	}
+}
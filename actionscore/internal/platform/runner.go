@@ -0,0 +1,146 @@
+package platform
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Runner describes the machine a job runs on. Facts that can't be determined
+// are left zero.
+type Runner struct {
+	// RUNNER_OS, RUNNER_ARCH and RUNNER_NAME, e.g. "Linux", "X64" and
+	// "GitHub Actions 2".
+	OS   string
+	Arch string
+	Name string
+	// RUNNER_TEMP and RUNNER_TOOL_CACHE.
+	Temp      string
+	ToolCache string
+	// RUNNER_ENVIRONMENT, "github-hosted" or "self-hosted".
+	Environment  string
+	GitHubHosted bool
+	// ImageOS and ImageVersion are only set on GitHub-hosted images, e.g.
+	// "ubuntu24" and "20241015.1.0".
+	ImageOS      string
+	ImageVersion string
+
+	CPUs int
+	// Total physical memory in bytes.
+	Memory uint64
+	// Bytes available on the file system holding Temp, or the working
+	// directory if Temp is unset.
+	FreeDisk uint64
+	// Whether the job is running inside a container.
+	Container bool
+}
+
+// GetRunner returns the facts about the current runner.
+func GetRunner() Runner {
+	return defaultDetector.Runner()
+}
+
+// Runner looks up the runner facts. Unlike Details it is not memoized, since
+// free disk space and the environment change while a job runs.
+func (d *Detector) Runner() Runner {
+	goos := d.GOOS
+	if goos == "" {
+		goos = Platform
+	}
+	r := Runner{
+		OS:           d.getenv("RUNNER_OS"),
+		Arch:         d.getenv("RUNNER_ARCH"),
+		Name:         d.getenv("RUNNER_NAME"),
+		Temp:         d.getenv("RUNNER_TEMP"),
+		ToolCache:    d.getenv("RUNNER_TOOL_CACHE"),
+		Environment:  d.getenv("RUNNER_ENVIRONMENT"),
+		ImageOS:      d.getenv("ImageOS"),
+		ImageVersion: d.getenv("ImageVersion"),
+	}
+	r.GitHubHosted = r.Environment == "github-hosted"
+	if d.NumCPU != nil {
+		r.CPUs = d.NumCPU()
+	} else {
+		r.CPUs = runtime.NumCPU()
+	}
+	r.Memory = d.memory(goos)
+
+	dir := r.Temp
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	if dir != "" {
+		freeDisk := d.FreeDisk
+		if freeDisk == nil {
+			freeDisk = freeDiskSpace
+		}
+		r.FreeDisk, _ = freeDisk(dir)
+	}
+
+	if goos == "linux" {
+		r.Container = d.inContainer()
+	}
+	return r
+}
+
+func (d *Detector) getenv(key string) string {
+	if d.Getenv != nil {
+		return d.Getenv(key)
+	}
+	return os.Getenv(key)
+}
+
+func (d *Detector) memory(goos string) uint64 {
+	switch goos {
+	case "linux":
+		content, err := d.readFile("/proc/meminfo")
+		if err != nil {
+			return 0
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 && fields[0] == "MemTotal:" {
+				kb, _ := strconv.ParseUint(fields[1], 10, 64)
+				return kb * 1024
+			}
+		}
+		return 0
+	case "darwin":
+		out, err := d.command("sysctl", "-n", "hw.memsize")
+		if err != nil {
+			return 0
+		}
+		n, _ := strconv.ParseUint(strings.TrimSpace(string(out)), 10, 64)
+		return n
+	case "windows":
+		out, err := d.command("powershell", "-command", "(Get-CimInstance -ClassName Win32_ComputerSystem).TotalPhysicalMemory")
+		if err != nil {
+			return 0
+		}
+		n, _ := strconv.ParseUint(strings.TrimSpace(string(out)), 10, 64)
+		return n
+	default:
+		return 0
+	}
+}
+
+var containerCgroupMarkers = []string{"docker", "kubepods", "containerd", "libpod", "lxc"}
+
+func (d *Detector) inContainer() bool {
+	for _, name := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := d.readFile(name); err == nil {
+			return true
+		}
+	}
+	content, err := d.readFile("/proc/1/cgroup")
+	if err != nil {
+		return false
+	}
+	for _, marker := range containerCgroupMarkers {
+		if strings.Contains(string(content), marker) {
+			return true
+		}
+	}
+	return false
+}
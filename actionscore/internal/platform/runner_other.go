@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || dragonfly || freebsd || windows)
+
+package platform
+
+import "errors"
+
+func freeDiskSpace(path string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
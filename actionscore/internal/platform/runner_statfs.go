@@ -0,0 +1,14 @@
+//go:build linux || darwin || dragonfly || freebsd
+
+package platform
+
+import "syscall"
+
+func freeDiskSpace(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	err := syscall.Statfs(path, &st)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
@@ -0,0 +1,128 @@
+package summary
+
+import (
+	"regexp"
+	"strings"
+)
+
+// GitHub only renders the markdown features below when they start a new
+// block. The rest of the builder emits HTML, and an HTML block swallows every
+// line up to the next blank line, so each of these is surrounded by blank
+// lines.
+
+type SummaryAlertType string
+
+const (
+	SummaryAlertNote      SummaryAlertType = "NOTE"
+	SummaryAlertTip       SummaryAlertType = "TIP"
+	SummaryAlertImportant SummaryAlertType = "IMPORTANT"
+	SummaryAlertWarning   SummaryAlertType = "WARNING"
+	SummaryAlertCaution   SummaryAlertType = "CAUTION"
+)
+
+type SummaryTaskListItem struct {
+	Text    string
+	Checked bool
+}
+
+func (s *Summary) addBlock(block string) *Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buffer != "" && !strings.HasSuffix(s.buffer, eol+eol) {
+		if strings.HasSuffix(s.buffer, eol) {
+			s.buffer += eol
+		} else {
+			s.buffer += eol + eol
+		}
+	}
+	s.buffer += block + eol + eol
+	return s
+}
+
+func splitLines(text string) []string {
+	return strings.Split(strings.ReplaceAll(strings.TrimRight(text, "\r\n"), "\r\n", "\n"), "\n")
+}
+
+// AddAlert adds a `> [!TYPE]` alert blockquote. text may span several lines.
+func (s *Summary) AddAlert(alertType SummaryAlertType, text string) *Summary {
+	lines := []string{"> [!" + string(alertType) + "]"}
+	for _, line := range splitLines(text) {
+		if line == "" {
+			lines = append(lines, ">")
+		} else {
+			lines = append(lines, "> "+line)
+		}
+	}
+	return s.addBlock(strings.Join(lines, eol))
+}
+
+var backtickRunRegExp = regexp.MustCompile("`{3,}")
+
+// AddMermaid adds a ```mermaid fenced code block, which GitHub renders as a
+// diagram.
+func (s *Summary) AddMermaid(diagram string) *Summary {
+	fence := "```"
+	for _, run := range backtickRunRegExp.FindAllString(diagram, -1) {
+		if len(run) >= len(fence) {
+			fence = strings.Repeat("`", len(run)+1)
+		}
+	}
+	lines := append([]string{fence + "mermaid"}, splitLines(diagram)...)
+	lines = append(lines, fence)
+	return s.addBlock(strings.Join(lines, eol))
+}
+
+// AddTaskList adds a `- [ ]` / `- [x]` task list.
+func (s *Summary) AddTaskList(items []SummaryTaskListItem) *Summary {
+	var lines []string
+	for _, item := range items {
+		marker := "- [ ] "
+		if item.Checked {
+			marker = "- [x] "
+		}
+		for i, line := range splitLines(item.Text) {
+			if i == 0 {
+				lines = append(lines, marker+line)
+			} else {
+				lines = append(lines, "  "+line)
+			}
+		}
+	}
+	return s.addBlock(strings.Join(lines, eol))
+}
+
+// AddFootnote adds the definition for a footnote referenced elsewhere with
+// SummaryFootnoteRef. GitHub moves the definitions to the end of the summary.
+func (s *Summary) AddFootnote(label string, text string) *Summary {
+	lines := splitLines(text)
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = "    " + lines[i]
+		}
+	}
+	return s.addBlock(SummaryFootnoteRef(label) + ": " + strings.Join(lines, eol))
+}
+
+var footnoteLabelReplacer = strings.NewReplacer(" ", "-", "]", "", "[", "", "^", "")
+
+// SummaryFootnoteRef returns the `[^label]` reference to use in text.
+func SummaryFootnoteRef(label string) string {
+	return "[^" + footnoteLabelReplacer.Replace(label) + "]"
+}
+
+var emojiShortcodeRegExp = regexp.MustCompile(`^[a-z0-9_+-]+$`)
+
+// SummaryEmoji returns the `:shortcode:` for an emoji such as "rocket" or
+// ":white_check_mark:". Anything that can't be a shortcode is returned as-is.
+func SummaryEmoji(shortcode string) string {
+	name := strings.Trim(shortcode, ":")
+	if !emojiShortcodeRegExp.MatchString(name) {
+		return shortcode
+	}
+	return ":" + name + ":"
+}
+
+// AddEmoji appends an emoji shortcode inline, without starting a new block.
+func (s *Summary) AddEmoji(shortcode string) *Summary {
+	return s.add(SummaryEmoji(shortcode), false)
+}
@@ -0,0 +1,430 @@
+package summary
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RenderHTML turns the contents of a job summary (Summary.Stringify output or
+// a GITHUB_STEP_SUMMARY file) into a self-contained HTML page styled to look
+// roughly like GitHub's rendering. It understands the raw HTML the builder
+// emits plus the subset of GitHub-flavored markdown that shows up in
+// summaries; it is meant for previews and snapshot tests, not as a general
+// markdown renderer. The output is deterministic for a given input.
+func RenderHTML(summary string, options SummaryRenderOptions) string {
+	title := "Job summary"
+	if options.Title != nil {
+		title = *options.Title
+	}
+	r := &renderer{footnoteIndex: map[string]int{}}
+	body := r.blocks(splitLines(summary))
+	body += r.footnotesSection()
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n")
+	b.WriteString("<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	b.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	b.WriteString("<style>\n" + renderCSS + "</style>\n")
+	b.WriteString("</head>\n<body>\n<main class=\"markdown-body\">\n")
+	b.WriteString(body)
+	b.WriteString("</main>\n</body>\n</html>\n")
+	return b.String()
+}
+
+type SummaryRenderOptions struct {
+	Title *string
+}
+
+type renderer struct {
+	footnotes     []renderedFootnote
+	footnoteIndex map[string]int
+}
+
+type renderedFootnote struct {
+	label string
+	html  string
+}
+
+var (
+	htmlBlockRegExp     = regexp.MustCompile(`^ {0,3}<(/?[A-Za-z][A-Za-z0-9-]*|!--)`)
+	fenceRegExp         = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})\\s*([^`\\s]*)")
+	headingRegExp       = regexp.MustCompile(`^ {0,3}(#{1,6})(?:\s+(.*?))?\s*#*\s*$`)
+	thematicBreakRegExp = regexp.MustCompile(`^ {0,3}(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	blockquoteRegExp    = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+	listItemRegExp      = regexp.MustCompile(`^( {0,3})([-*+]|\d{1,9}[.)])\s+(.*)$`)
+	footnoteDefRegExp   = regexp.MustCompile(`^\[\^([^\]]+)\]:\s?(.*)$`)
+	alertRegExp         = regexp.MustCompile(`^\[!(NOTE|TIP|IMPORTANT|WARNING|CAUTION)\]\s*$`)
+	taskRegExp          = regexp.MustCompile(`(?s)^\[([ xX])\]\s+(.*)$`)
+)
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func (r *renderer) blocks(lines []string) string {
+	var out strings.Builder
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isBlank(line):
+			i++
+
+		case fenceRegExp.MatchString(line):
+			m := fenceRegExp.FindStringSubmatch(line)
+			fence, lang := m[1], m[2]
+			var code []string
+			i++
+			for i < len(lines) {
+				trimmed := strings.TrimSpace(lines[i])
+				if strings.HasPrefix(trimmed, fence[:1]) && len(trimmed) >= len(fence) && strings.Trim(trimmed, fence[:1]) == "" {
+					i++
+					break
+				}
+				code = append(code, lines[i])
+				i++
+			}
+			escaped := html.EscapeString(strings.Join(code, "\n"))
+			switch lang {
+			case "":
+				out.WriteString("<pre><code>" + escaped + "</code></pre>\n")
+			case "mermaid":
+				out.WriteString("<pre class=\"mermaid\"><code>" + escaped + "</code></pre>\n")
+			default:
+				out.WriteString("<pre><code class=\"language-" + html.EscapeString(lang) + "\">" + escaped + "</code></pre>\n")
+			}
+
+		case htmlBlockRegExp.MatchString(line):
+			for i < len(lines) && !isBlank(lines[i]) {
+				out.WriteString(lines[i] + "\n")
+				i++
+			}
+
+		case headingRegExp.MatchString(line):
+			m := headingRegExp.FindStringSubmatch(line)
+			level := strconv.Itoa(len(m[1]))
+			out.WriteString("<h" + level + ">" + r.inline(m[2]) + "</h" + level + ">\n")
+			i++
+
+		case thematicBreakRegExp.MatchString(line):
+			out.WriteString("<hr>\n")
+			i++
+
+		case blockquoteRegExp.MatchString(line):
+			var quoted []string
+			for i < len(lines) && blockquoteRegExp.MatchString(lines[i]) {
+				quoted = append(quoted, blockquoteRegExp.FindStringSubmatch(lines[i])[1])
+				i++
+			}
+			if m := alertRegExp.FindStringSubmatch(quoted[0]); m != nil {
+				kind := strings.ToLower(m[1])
+				out.WriteString("<div class=\"markdown-alert markdown-alert-" + kind + "\">\n")
+				out.WriteString("<p class=\"markdown-alert-title\">" + strings.ToUpper(kind[:1]) + kind[1:] + "</p>\n")
+				out.WriteString(r.blocks(quoted[1:]))
+				out.WriteString("</div>\n")
+			} else {
+				out.WriteString("<blockquote>\n" + r.blocks(quoted) + "</blockquote>\n")
+			}
+
+		case footnoteDefRegExp.MatchString(line):
+			m := footnoteDefRegExp.FindStringSubmatch(line)
+			text := []string{m[2]}
+			i++
+			for i < len(lines) && (strings.HasPrefix(lines[i], "    ") || strings.HasPrefix(lines[i], "\t") || (isBlank(lines[i]) && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "    "))) {
+				text = append(text, strings.TrimSpace(lines[i]))
+				i++
+			}
+			r.addFootnote(m[1], r.blocks(text))
+
+		case listItemRegExp.MatchString(line):
+			i = r.list(lines, i, &out)
+
+		default:
+			var para []string
+			for i < len(lines) && !isBlank(lines[i]) && (len(para) == 0 || !r.interruptsParagraph(lines[i])) {
+				para = append(para, strings.TrimSpace(lines[i]))
+				i++
+			}
+			out.WriteString("<p>" + r.inline(strings.Join(para, "\n")) + "</p>\n")
+		}
+	}
+	return out.String()
+}
+
+func (r *renderer) interruptsParagraph(line string) bool {
+	return fenceRegExp.MatchString(line) ||
+		htmlBlockRegExp.MatchString(line) ||
+		headingRegExp.MatchString(line) ||
+		thematicBreakRegExp.MatchString(line) ||
+		blockquoteRegExp.MatchString(line) ||
+		listItemRegExp.MatchString(line)
+}
+
+func (r *renderer) list(lines []string, i int, out *strings.Builder) int {
+	first := listItemRegExp.FindStringSubmatch(lines[i])
+	base := len(first[1])
+	ordered := first[2][0] >= '0' && first[2][0] <= '9'
+	if ordered {
+		start := strings.TrimRight(first[2], ".)")
+		if start != "1" {
+			out.WriteString("<ol start=\"" + strings.TrimLeft(start, "0") + "\">\n")
+		} else {
+			out.WriteString("<ol>\n")
+		}
+	} else {
+		out.WriteString("<ul>\n")
+	}
+
+	for i < len(lines) {
+		m := listItemRegExp.FindStringSubmatch(lines[i])
+		if m == nil || len(m[1]) >= base+2 {
+			break
+		}
+		if itemOrdered := m[2][0] >= '0' && m[2][0] <= '9'; itemOrdered != ordered {
+			break
+		}
+		indent := len(m[1]) + len(m[2]) + 1
+		content := []string{m[3]}
+		i++
+		for i < len(lines) && !isBlank(lines[i]) && !listItemRegExp.MatchString(lines[i]) {
+			content = append(content, strings.TrimSpace(lines[i]))
+			i++
+		}
+		// Anything indented under the item (usually a nested list) is
+		// rendered as blocks inside it.
+		var sub []string
+		for i < len(lines) && !isBlank(lines[i]) && strings.HasPrefix(lines[i], "  ") {
+			line := lines[i]
+			for n := 0; n < indent && strings.HasPrefix(line, " "); n++ {
+				line = line[1:]
+			}
+			sub = append(sub, line)
+			i++
+		}
+		var nested string
+		if len(sub) > 0 {
+			nested = "\n" + r.blocks(sub)
+		}
+
+		text := strings.Join(content, "\n")
+		if t := taskRegExp.FindStringSubmatch(text); t != nil {
+			checked := ""
+			if t[1] != " " {
+				checked = " checked"
+			}
+			out.WriteString("<li class=\"task-list-item\"><input type=\"checkbox\" disabled" + checked + "> " + r.inline(t[2]) + nested + "</li>\n")
+		} else {
+			out.WriteString("<li>" + r.inline(text) + nested + "</li>\n")
+		}
+	}
+
+	if ordered {
+		out.WriteString("</ol>\n")
+	} else {
+		out.WriteString("</ul>\n")
+	}
+	return i
+}
+
+func (r *renderer) addFootnote(label string, content string) {
+	content = strings.TrimSuffix(strings.TrimPrefix(content, "<p>"), "</p>\n")
+	if _, ok := r.footnoteIndex[label]; !ok {
+		r.footnoteIndex[label] = len(r.footnotes) + 1
+		r.footnotes = append(r.footnotes, renderedFootnote{label: label})
+	}
+	r.footnotes[r.footnoteIndex[label]-1].html = content
+}
+
+func (r *renderer) footnoteNumber(label string) int {
+	if _, ok := r.footnoteIndex[label]; !ok {
+		r.footnoteIndex[label] = len(r.footnotes) + 1
+		r.footnotes = append(r.footnotes, renderedFootnote{label: label})
+	}
+	return r.footnoteIndex[label]
+}
+
+func (r *renderer) footnotesSection() string {
+	if len(r.footnotes) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<section class=\"footnotes\">\n<ol>\n")
+	for _, footnote := range r.footnotes {
+		id := html.EscapeString(footnote.label)
+		fmt.Fprintf(&b, "<li id=\"fn-%s\">%s <a href=\"#fnref-%s\">↩</a></li>\n", id, footnote.html, id)
+	}
+	b.WriteString("</ol>\n</section>\n")
+	return b.String()
+}
+
+var (
+	codeSpanRegExp    = regexp.MustCompile("(`+)(.+?)(`+)")
+	imageRegExp       = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+	linkRegExp        = regexp.MustCompile(`\[([^\]^][^\]]*)\]\(([^)\s]+)\)`)
+	footnoteRefRegExp = regexp.MustCompile(`\[\^([^\]]+)\]`)
+	strongRegExp      = regexp.MustCompile(`(\*\*|__)(\S(?:.*?\S)?)(\*\*|__)`)
+	emphasisRegExp    = regexp.MustCompile(`(^|[^\w*])[*_](\S(?:.*?\S)?)[*_]($|[^\w*])`)
+	strikeRegExp      = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	emojiRegExp       = regexp.MustCompile(`:([a-z0-9_+-]+):`)
+)
+
+// inline renders inline markdown. Raw HTML is passed through untouched, like
+// GitHub does before sanitizing.
+func (r *renderer) inline(text string) string {
+	var out strings.Builder
+	for {
+		loc := codeSpanRegExp.FindStringSubmatchIndex(text)
+		if loc == nil {
+			out.WriteString(r.inlineText(text))
+			break
+		}
+		open, code, close := text[loc[2]:loc[3]], text[loc[4]:loc[5]], text[loc[6]:loc[7]]
+		if open != close {
+			out.WriteString(r.inlineText(text[:loc[1]]))
+			text = text[loc[1]:]
+			continue
+		}
+		out.WriteString(r.inlineText(text[:loc[0]]))
+		out.WriteString("<code>" + html.EscapeString(strings.TrimSpace(code)) + "</code>")
+		text = text[loc[1]:]
+	}
+	return out.String()
+}
+
+func (r *renderer) inlineText(text string) string {
+	text = imageRegExp.ReplaceAllString(text, `<img src="$2" alt="$1">`)
+	text = footnoteRefRegExp.ReplaceAllStringFunc(text, func(ref string) string {
+		label := footnoteRefRegExp.FindStringSubmatch(ref)[1]
+		id := html.EscapeString(label)
+		return fmt.Sprintf("<sup><a href=\"#fn-%s\" id=\"fnref-%s\">%d</a></sup>", id, id, r.footnoteNumber(label))
+	})
+	text = linkRegExp.ReplaceAllString(text, `<a href="$2">$1</a>`)
+	text = strongRegExp.ReplaceAllString(text, "<strong>$2</strong>")
+	text = emphasisRegExp.ReplaceAllString(text, "$1<em>$2</em>$3")
+	text = strikeRegExp.ReplaceAllString(text, "<del>$1</del>")
+	text = emojiRegExp.ReplaceAllStringFunc(text, func(shortcode string) string {
+		if emoji, ok := emojis[strings.Trim(shortcode, ":")]; ok {
+			return emoji
+		}
+		return shortcode
+	})
+	return text
+}
+
+// The emoji most commonly seen in CI reports. Anything else is left as its
+// shortcode.
+var emojis = map[string]string{
+	"+1":                         "👍",
+	"-1":                         "👎",
+	"thumbsup":                   "👍",
+	"thumbsdown":                 "👎",
+	"white_check_mark":           "✅",
+	"heavy_check_mark":           "✔️",
+	"x":                          "❌",
+	"heavy_multiplication_x":     "✖️",
+	"warning":                    "⚠️",
+	"no_entry":                   "⛔",
+	"no_entry_sign":              "🚫",
+	"information_source":         "ℹ️",
+	"question":                   "❓",
+	"exclamation":                "❗",
+	"rocket":                     "🚀",
+	"tada":                       "🎉",
+	"sparkles":                   "✨",
+	"fire":                       "🔥",
+	"boom":                       "💥",
+	"bug":                        "🐛",
+	"construction":               "🚧",
+	"rotating_light":             "🚨",
+	"memo":                       "📝",
+	"package":                    "📦",
+	"lock":                       "🔒",
+	"key":                        "🔑",
+	"link":                       "🔗",
+	"gear":                       "⚙️",
+	"wrench":                     "🔧",
+	"hammer":                     "🔨",
+	"test_tube":                  "🧪",
+	"clipboard":                  "📋",
+	"books":                      "📚",
+	"bulb":                       "💡",
+	"eyes":                       "👀",
+	"zap":                        "⚡",
+	"star":                       "⭐",
+	"hourglass":                  "⌛",
+	"stopwatch":                  "⏱️",
+	"checkered_flag":             "🏁",
+	"recycle":                    "♻️",
+	"broom":                      "🧹",
+	"skull":                      "💀",
+	"green_circle":               "🟢",
+	"yellow_circle":              "🟡",
+	"red_circle":                 "🔴",
+	"white_circle":               "⚪",
+	"large_blue_circle":          "🔵",
+	"arrow_up":                   "⬆️",
+	"arrow_down":                 "⬇️",
+	"heavy_minus_sign":           "➖",
+	"heavy_plus_sign":            "➕",
+	"chart_with_upwards_trend":   "📈",
+	"chart_with_downwards_trend": "📉",
+}
+
+const renderCSS = `body {
+  margin: 0;
+  background: #ffffff;
+  color: #1f2328;
+  font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", "Noto Sans", Helvetica, Arial, sans-serif;
+  font-size: 14px;
+  line-height: 1.5;
+}
+.markdown-body {
+  box-sizing: border-box;
+  max-width: 1012px;
+  margin: 24px auto;
+  padding: 16px 24px;
+  border: 1px solid #d1d9e0;
+  border-radius: 6px;
+}
+.markdown-body > :first-child { margin-top: 0; }
+h1, h2, h3, h4, h5, h6 { margin: 24px 0 16px; font-weight: 600; line-height: 1.25; }
+h1 { font-size: 2em; padding-bottom: .3em; border-bottom: 1px solid #d1d9e0; }
+h2 { font-size: 1.5em; padding-bottom: .3em; border-bottom: 1px solid #d1d9e0; }
+h3 { font-size: 1.25em; }
+h4 { font-size: 1em; }
+h5 { font-size: .875em; }
+h6 { font-size: .85em; color: #59636e; }
+p, blockquote, ul, ol, dl, table, pre, details, .markdown-alert { margin: 0 0 16px; }
+a { color: #0969da; text-decoration: none; }
+a:hover { text-decoration: underline; }
+hr { height: .25em; margin: 24px 0; padding: 0; border: 0; background: #d1d9e0; }
+blockquote { padding: 0 1em; color: #59636e; border-left: .25em solid #d1d9e0; }
+ul, ol { padding-left: 2em; }
+li + li { margin-top: .25em; }
+.task-list-item { list-style-type: none; }
+.task-list-item input { margin: 0 .2em .25em -1.4em; vertical-align: middle; }
+code, pre { font-family: ui-monospace, SFMono-Regular, "SF Mono", Menlo, Consolas, "Liberation Mono", monospace; font-size: 85%; }
+code { padding: .2em .4em; background: rgba(129, 139, 152, .12); border-radius: 6px; }
+pre { padding: 16px; overflow: auto; line-height: 1.45; background: #f6f8fa; border-radius: 6px; }
+pre code { padding: 0; background: transparent; font-size: 100%; }
+pre.mermaid { border: 1px dashed #d1d9e0; }
+table { display: block; width: max-content; max-width: 100%; overflow: auto; border-spacing: 0; border-collapse: collapse; }
+th, td { padding: 6px 13px; border: 1px solid #d1d9e0; }
+th { font-weight: 600; }
+tr { background: #ffffff; border-top: 1px solid #d1d9e0; }
+tr:nth-child(2n) { background: #f6f8fa; }
+img { max-width: 100%; }
+details summary { cursor: pointer; }
+.markdown-alert { padding: .5rem 1rem; border-left: .25em solid; }
+.markdown-alert > :last-child { margin-bottom: 0; }
+.markdown-alert-title { font-weight: 500; margin-bottom: 4px; }
+.markdown-alert-note { border-color: #0969da; } .markdown-alert-note .markdown-alert-title { color: #0969da; }
+.markdown-alert-tip { border-color: #1a7f37; } .markdown-alert-tip .markdown-alert-title { color: #1a7f37; }
+.markdown-alert-important { border-color: #8250df; } .markdown-alert-important .markdown-alert-title { color: #8250df; }
+.markdown-alert-warning { border-color: #9a6700; } .markdown-alert-warning .markdown-alert-title { color: #9a6700; }
+.markdown-alert-caution { border-color: #d1242f; } .markdown-alert-caution .markdown-alert-title { color: #d1242f; }
+.footnotes { font-size: 12px; color: #59636e; border-top: 1px solid #d1d9e0; padding-top: 8px; }
+`
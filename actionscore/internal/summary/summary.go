@@ -1,14 +1,27 @@
 package summary
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/filelock"
 )
 
 const SummaryEnvVar = "GITHUB_STEP_SUMMARY"
 const SummaryDocsURL = "https://docs.github.com/actions/using-workflows/workflow-commands-for-github-actions#adding-a-job-summary"
 
+// GitHub refuses to render a step summary larger than this, and drops the
+// whole thing rather than truncating it.
+const SummaryMaxSize = 1024 * 1024
+
+var ErrSummaryTooLarge = errors.New("job summary is too large")
+
 // [](SummaryTableCell | string)
 type SummaryTableRow = []any
 
@@ -17,6 +30,7 @@ type SummaryTableCell struct {
 	Header  *bool
 	Colspan *string
 	Rowspan *string
+	Align   *string
 }
 
 type SummaryImageOptions struct {
@@ -24,11 +38,35 @@ type SummaryImageOptions struct {
 	Height *string
 }
 
+// What Write does when the buffer doesn't fit in the space left in the
+// summary file.
+type SummaryOverflowPolicy int
+
+const (
+	// Return an error wrapping ErrSummaryTooLarge and leave both the file and
+	// the buffer untouched.
+	SummaryOverflowError SummaryOverflowPolicy = iota
+	// Write as many whole rows as fit, followed by a note saying how many
+	// rows were left out. Tables are cut between their rows and closed;
+	// everything else is cut between lines.
+	SummaryOverflowTruncate
+	// Like SummaryOverflowTruncate, but the rows left out are written to
+	// SummaryWriteOptions.SpillPath so they can be uploaded as an artifact.
+	SummaryOverflowSpill
+)
+
 type SummaryWriteOptions struct {
 	Overwrite *bool
+	Overflow  *SummaryOverflowPolicy
+	SpillPath *string
+	// Defaults to SummaryMaxSize.
+	MaxSize *int
 }
 
+// Summary is safe for concurrent use. Each Add* call appends its content as a
+// unit, so parallel workers can't interleave inside one element.
 type Summary struct {
+	mu            sync.Mutex
 	buffer        string
 	filePathValue *string
 }
@@ -49,7 +87,7 @@ func (s *Summary) filePath() (string, error) {
 
 	stats, err := os.Stat(pathFromEnv)
 	if err == nil {
-		if stats.Mode()&os.ModePerm != 0666 {
+		if stats.Mode().Perm()&0222 == 0 {
 			err = fmt.Errorf("file %q is not writable", pathFromEnv)
 		}
 	}
@@ -62,9 +100,14 @@ func (s *Summary) filePath() (string, error) {
 }
 
 func (s *Summary) wrap(tag string, content *string, attrs map[string]string) string {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 	var htmlAttrs string
-	for k, v := range attrs {
-		htmlAttrs += fmt.Sprintf(` %s="%s"`, k, v)
+	for _, k := range keys {
+		htmlAttrs += fmt.Sprintf(` %s="%s"`, k, attrs[k])
 	}
 
 	if content == nil || *content == "" {
@@ -74,35 +117,171 @@ func (s *Summary) wrap(tag string, content *string, attrs map[string]string) str
 }
 
 func (s *Summary) Write(options SummaryWriteOptions) (*Summary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.write(options)
+}
+
+func (s *Summary) write(options SummaryWriteOptions) (*Summary, error) {
 	var overwrite bool
 	if options.Overwrite != nil {
 		overwrite = *options.Overwrite
 	}
+	overflow := SummaryOverflowError
+	if options.Overflow != nil {
+		overflow = *options.Overflow
+	}
+	maxSize := SummaryMaxSize
+	if options.MaxSize != nil {
+		maxSize = *options.MaxSize
+	}
 	filePath, err := s.filePath()
 	if err != nil {
 		return nil, err
 	}
-	var writeFunc func(string, []byte) error
+	// Appending nothing can't overflow, even to a file that already has.
+	if !overwrite && s.buffer == "" {
+		return s, nil
+	}
+
+	fit := func(existing int64) (string, error) {
+		content := s.buffer
+		if available := int64(maxSize) - existing; int64(len(content)) > available {
+			return s.fitContent(available, maxSize, overflow, options.SpillPath)
+		}
+		return content, nil
+	}
 	if overwrite {
-		writeFunc = func(s string, b []byte) error {
-			return os.WriteFile(s, b, 0666)
+		content, err := fit(0)
+		if err != nil {
+			return nil, err
+		}
+		err = filelock.WriteFile(filePath, []byte(content))
+		if err != nil {
+			return nil, err
 		}
 	} else {
-		writeFunc = func(s string, b []byte) error {
-			f, err := os.OpenFile(s, os.O_APPEND|os.O_WRONLY, 0666)
+		// Hold the lock across the size check and the write so another
+		// process can't push the file over the limit in between.
+		err = filelock.WithLock(filePath, func(f *os.File) error {
+			stats, err := f.Stat()
 			if err != nil {
 				return err
 			}
-			defer f.Close()
-			_, err = f.Write(b)
+			content, err := fit(stats.Size())
+			if err != nil {
+				return err
+			}
+			_, err = f.Write([]byte(content))
 			return err
+		})
+		if err != nil {
+			return nil, err
 		}
 	}
-	err = writeFunc(filePath, []byte(s.buffer))
-	if err != nil {
-		return nil, err
+	s.buffer = ""
+	return s, nil
+}
+
+// fitContent returns the part of the buffer that fits in available bytes,
+// cut at a row boundary and followed by a note about what was left out.
+func (s *Summary) fitContent(available int64, maxSize int, overflow SummaryOverflowPolicy, spillPath *string) (string, error) {
+	tooLarge := fmt.Errorf("%w: %d bytes with only %d of %d bytes left in %s", ErrSummaryTooLarge, len(s.buffer), max(available, 0), maxSize, SummaryEnvVar)
+	switch overflow {
+	case SummaryOverflowTruncate:
+	case SummaryOverflowSpill:
+		if spillPath == nil || *spillPath == "" {
+			return "", fmt.Errorf("%w and no SpillPath was given", tooLarge)
+		}
+	default:
+		return "", tooLarge
+	}
+
+	rows := summaryRows(s.buffer)
+	note := func(omitted int) string {
+		if overflow == SummaryOverflowSpill {
+			return fmt.Sprintf("<p>…%d rows omitted, see %s</p>%s", omitted, filepath.Base(*spillPath), eol)
+		}
+		return fmt.Sprintf("<p>…%d rows omitted</p>%s", omitted, eol)
+	}
+
+	kept := 0
+	var size int64
+	for kept < len(rows) {
+		next := size + int64(len(rows[kept].text))
+		if next+int64(len(rows[kept].close))+int64(len(note(len(rows)-kept-1))) > available {
+			break
+		}
+		size = next
+		kept++
+	}
+	var content, spill strings.Builder
+	for _, row := range rows[:kept] {
+		content.WriteString(row.text)
+	}
+	if kept > 0 && rows[kept-1].close != "" {
+		// The cut is inside a table: close it here and reopen it in the
+		// spill file.
+		content.WriteString(rows[kept-1].close)
+		spill.WriteString("<table>")
 	}
-	return s.EmptyBuffer(), nil
+	content.WriteString(note(len(rows) - kept))
+	if int64(content.Len()) > available {
+		return "", tooLarge
+	}
+
+	if overflow == SummaryOverflowSpill {
+		for _, row := range rows[kept:] {
+			spill.WriteString(row.text)
+		}
+		err := os.WriteFile(*spillPath, []byte(spill.String()), 0666)
+		if err != nil {
+			return "", err
+		}
+	}
+	return content.String(), nil
+}
+
+// summaryRow is the unit fitContent cuts the buffer at: a line, or a row of
+// a table written by AddTable, which puts a whole table on one line.
+type summaryRow struct {
+	text string
+	// Closes the table the row is in when the buffer is cut after it.
+	close string
+}
+
+func summaryRows(buffer string) []summaryRow {
+	lines := strings.SplitAfter(buffer, eol)
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	var rows []summaryRow
+	for _, line := range lines {
+		body, ok := strings.CutPrefix(line, "<table>")
+		if ok {
+			body, ok = strings.CutSuffix(body, "</table>"+eol)
+		}
+		if !ok || !strings.HasPrefix(body, "<tr>") {
+			rows = append(rows, summaryRow{text: line})
+			continue
+		}
+		trs := strings.SplitAfter(body, "</tr>")
+		if trs[len(trs)-1] == "" {
+			trs = trs[:len(trs)-1]
+		}
+		for i, tr := range trs {
+			row := summaryRow{text: tr, close: "</table>" + eol}
+			if i == 0 {
+				row.text = "<table>" + row.text
+			}
+			if i == len(trs)-1 {
+				row.text += row.close
+				row.close = ""
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
 }
 
 func ptr[T any](v T) *T {
@@ -110,18 +289,27 @@ func ptr[T any](v T) *T {
 }
 
 func (s *Summary) Clear() (*Summary, error) {
-	return s.EmptyBuffer().Write(SummaryWriteOptions{Overwrite: ptr(true)})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buffer = ""
+	return s.write(SummaryWriteOptions{Overwrite: ptr(true)})
 }
 
 func (s *Summary) Stringify() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.buffer
 }
 
 func (s *Summary) IsEmptyBuffer() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.buffer == ""
 }
 
 func (s *Summary) EmptyBuffer() *Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.buffer = ""
 	return s
 }
@@ -131,12 +319,17 @@ func (s *Summary) AddRaw(text string, addEOLRaw *bool) *Summary {
 	if addEOLRaw != nil {
 		addEOL = *addEOLRaw
 	}
+	return s.add(text, addEOL)
+}
+
+func (s *Summary) add(text string, addEOL bool) *Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.buffer += text
 	if addEOL {
-		return s.AddEOL()
-	} else {
-		return s
+		s.buffer += eol
 	}
+	return s
 }
 
 var eol = func() string {
@@ -148,7 +341,48 @@ var eol = func() string {
 }()
 
 func (s *Summary) AddEOL() *Summary {
-	s.buffer += eol
-	return s
+	return s.add("", true)
+}
+
+func (s *Summary) AddTable(rows []SummaryTableRow) *Summary {
+	var tableBody strings.Builder
+	for _, row := range rows {
+		var cells strings.Builder
+		for _, cell := range row {
+			switch cell := cell.(type) {
+			case string:
+				cells.WriteString(s.wrap("td", &cell, nil))
+			case SummaryTableCell:
+				cells.WriteString(s.wrapCell(cell))
+			case *SummaryTableCell:
+				cells.WriteString(s.wrapCell(*cell))
+			default:
+				data := fmt.Sprint(cell)
+				cells.WriteString(s.wrap("td", &data, nil))
+			}
+		}
+		cellsStr := cells.String()
+		tableBody.WriteString(s.wrap("tr", &cellsStr, nil))
+	}
+	tableBodyStr := tableBody.String()
+	element := s.wrap("table", &tableBodyStr, nil)
+	return s.add(element, true)
 }
 
+func (s *Summary) wrapCell(cell SummaryTableCell) string {
+	tag := "td"
+	if cell.Header != nil && *cell.Header {
+		tag = "th"
+	}
+	attrs := map[string]string{}
+	if cell.Colspan != nil && *cell.Colspan != "" {
+		attrs["colspan"] = *cell.Colspan
+	}
+	if cell.Rowspan != nil && *cell.Rowspan != "" {
+		attrs["rowspan"] = *cell.Rowspan
+	}
+	if cell.Align != nil && *cell.Align != "" {
+		attrs["align"] = *cell.Align
+	}
+	return s.wrap(tag, &cell.Data, attrs)
+}
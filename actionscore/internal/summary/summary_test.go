@@ -0,0 +1,390 @@
+package summary_test
+
+import (
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/summary"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+var eol = func() string {
+	if runtime.GOOS == "windows" {
+		return "\r\n"
+	}
+	return "\n"
+}()
+
+func TestAddTable(t *testing.T) {
+	s := summary.NewSummary()
+	s.AddTable([]summary.SummaryTableRow{
+		{summary.SummaryTableCell{Data: "foo", Header: ptr(true)}, summary.SummaryTableCell{Data: "bar", Header: ptr(true)}},
+		{"a", summary.SummaryTableCell{Data: "b", Colspan: ptr("2"), Align: ptr("right")}},
+	})
+	expected := `<table><tr><th>foo</th><th>bar</th></tr><tr><td>a</td><td align="right" colspan="2">b</td></tr></table>` + eol
+	if got := s.Stringify(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+type benchmarkRow struct {
+	Name     string        `summary:"Benchmark"`
+	Duration time.Duration `summary:"Time,align=right"`
+	Allocs   *int          `summary:",align=center"`
+	Note     string        `summary:"-"`
+	internal string
+}
+
+func TestAddTableFrom(t *testing.T) {
+	rows := []benchmarkRow{
+		{Name: "b<2>", Duration: 2 * time.Second, Allocs: ptr(4)},
+		{Name: "a", Duration: 3 * time.Second},
+		{Name: "c", Duration: time.Second, Allocs: ptr(1), Note: "ignored", internal: "ignored"},
+	}
+	s := summary.NewSummary()
+	_, err := s.AddTableFrom(rows, summary.SummaryTableFromOptions{
+		Format: map[string]func(any) string{
+			"Duration": func(v any) string { return fmt.Sprintf("<b>%.0fs</b>", v.(time.Duration).Seconds()) },
+		},
+		SortBy:     ptr("Duration"),
+		Descending: ptr(true),
+		Limit:      ptr(2),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<table>` +
+		`<tr><th>Benchmark</th><th align="right">Time</th><th align="center">Allocs</th></tr>` +
+		`<tr><td>a</td><td align="right"><b>3s</b></td><td align="center"/></tr>` +
+		`<tr><td>b&lt;2&gt;</td><td align="right"><b>2s</b></td><td align="center">4</td></tr>` +
+		`</table>` + eol
+	if got := s.Stringify(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestAddTableFromSortNil(t *testing.T) {
+	rows := []benchmarkRow{
+		{Name: "b", Allocs: ptr(4)},
+		{Name: "a"},
+		{Name: "c", Allocs: ptr(1)},
+		{Name: "d"},
+	}
+	for _, descending := range []bool{false, true} {
+		s := summary.NewSummary()
+		_, err := s.AddTableFrom(rows, summary.SummaryTableFromOptions{SortBy: ptr("Allocs"), Descending: ptr(descending)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		order := "<td>a</td>.*<td>d</td>.*<td>c</td>.*<td>b</td>"
+		if descending {
+			order = "<td>b</td>.*<td>c</td>.*<td>a</td>.*<td>d</td>"
+		}
+		if !regexp.MustCompile(order).MatchString(s.Stringify()) {
+			t.Errorf("descending=%v: expected rows in the order %s, got %q", descending, order, s.Stringify())
+		}
+	}
+}
+
+func TestAddTableFromErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		slice   any
+		options summary.SummaryTableFromOptions
+	}{
+		{"not a slice", benchmarkRow{}, summary.SummaryTableFromOptions{}},
+		{"not structs", []int{1, 2}, summary.SummaryTableFromOptions{}},
+		{"bad tag", []struct {
+			A string `summary:"A,align=middle"`
+		}{}, summary.SummaryTableFromOptions{}},
+		{"unknown sort column", []benchmarkRow{}, summary.SummaryTableFromOptions{SortBy: ptr("Time")}},
+		{"unknown format column", []benchmarkRow{}, summary.SummaryTableFromOptions{Format: map[string]func(any) string{"Nope": nil}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := summary.NewSummary()
+			if _, err := s.AddTableFrom(tt.slice, tt.options); err == nil {
+				t.Error("expected an error")
+			}
+			if !s.IsEmptyBuffer() {
+				t.Errorf("expected empty buffer, got %q", s.Stringify())
+			}
+		})
+	}
+}
+
+func setupSummaryFile(t *testing.T, initial string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "step_summary")
+	err := os.WriteFile(path, []byte(initial), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(summary.SummaryEnvVar, path)
+	return path
+}
+
+func assertFile(t *testing.T, path string, expected string) {
+	t.Helper()
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bytes) != expected {
+		t.Errorf("expected %q, got %q", expected, string(bytes))
+	}
+}
+
+func TestWriteOverflowError(t *testing.T) {
+	path := setupSummaryFile(t, "12345")
+
+	s := summary.NewSummary().AddRaw("abcdef", ptr(true))
+	_, err := s.Write(summary.SummaryWriteOptions{MaxSize: ptr(10)})
+	if !errors.Is(err, summary.ErrSummaryTooLarge) {
+		t.Fatalf("expected ErrSummaryTooLarge, got %v", err)
+	}
+	assertFile(t, path, "12345")
+	if s.IsEmptyBuffer() {
+		t.Error("expected buffer to be kept")
+	}
+
+	_, err = s.Write(summary.SummaryWriteOptions{Overwrite: ptr(true), MaxSize: ptr(10)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, path, "abcdef"+eol)
+}
+
+func TestWriteOverflowEmptyBuffer(t *testing.T) {
+	for _, initial := range []string{"1234567890", "12345678901"} {
+		path := setupSummaryFile(t, initial)
+		for _, overflow := range []summary.SummaryOverflowPolicy{summary.SummaryOverflowError, summary.SummaryOverflowTruncate} {
+			_, err := summary.NewSummary().Write(summary.SummaryWriteOptions{Overflow: &overflow, MaxSize: ptr(10)})
+			if err != nil {
+				t.Errorf("%d bytes, policy %d: expected an empty append to do nothing, got %v", len(initial), overflow, err)
+			}
+			assertFile(t, path, initial)
+		}
+	}
+}
+
+func TestWriteOverflowTruncate(t *testing.T) {
+	path := setupSummaryFile(t, "existing"+eol)
+
+	s := summary.NewSummary()
+	for i := 0; i < 10; i++ {
+		s.AddRaw(fmt.Sprintf("line %d", i), ptr(true))
+	}
+	note := "<p>…7 rows omitted</p>" + eol
+	maxSize := len("existing"+eol) + 3*len("line 0"+eol) + len(note)
+	_, err := s.Write(summary.SummaryWriteOptions{
+		Overflow: ptr(summary.SummaryOverflowTruncate),
+		MaxSize:  ptr(maxSize),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, path, "existing"+eol+"line 0"+eol+"line 1"+eol+"line 2"+eol+note)
+	if !s.IsEmptyBuffer() {
+		t.Error("expected buffer to be emptied")
+	}
+}
+
+func TestWriteOverflowSpill(t *testing.T) {
+	path := setupSummaryFile(t, "")
+	spillPath := filepath.Join(t.TempDir(), "summary-overflow.html")
+
+	one := strings.Repeat("1", 100)
+	two := strings.Repeat("2", 100)
+	three := strings.Repeat("3", 100)
+	s := summary.NewSummary().AddRaw(one, ptr(true)).AddRaw(two, ptr(true)).AddRaw(three, ptr(true))
+	note := "<p>…2 rows omitted, see summary-overflow.html</p>" + eol
+	_, err := s.Write(summary.SummaryWriteOptions{
+		Overflow:  ptr(summary.SummaryOverflowSpill),
+		SpillPath: &spillPath,
+		MaxSize:   ptr(len(one+eol) + len(note)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, path, one+eol+note)
+	assertFile(t, spillPath, two+eol+three+eol)
+}
+
+func TestWriteOverflowTable(t *testing.T) {
+	path := setupSummaryFile(t, "")
+	spillPath := filepath.Join(t.TempDir(), "summary-overflow.html")
+
+	rows := []summary.SummaryTableRow{{summary.SummaryTableCell{Data: "Test", Header: ptr(true)}}}
+	for i := 0; i < 100; i++ {
+		rows = append(rows, summary.SummaryTableRow{fmt.Sprintf("test %02d", i)})
+	}
+	s := summary.NewSummary().AddRaw("<h1>Report</h1>", ptr(true)).AddTable(rows)
+	kept := "<h1>Report</h1>" + eol +
+		"<table><tr><th>Test</th></tr><tr><td>test 00</td></tr><tr><td>test 01</td></tr></table>" + eol +
+		"<p>…98 rows omitted, see summary-overflow.html</p>" + eol
+	_, err := s.Write(summary.SummaryWriteOptions{
+		Overflow:  ptr(summary.SummaryOverflowSpill),
+		SpillPath: &spillPath,
+		MaxSize:   ptr(len(kept) + 10),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, path, kept)
+	bytes, err := os.ReadFile(spillPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spill := string(bytes)
+	if !strings.HasPrefix(spill, "<table><tr><td>test 02</td></tr>") || !strings.HasSuffix(spill, "<tr><td>test 99</td></tr></table>"+eol) {
+		t.Errorf("expected the rest of the table in the spill file, got %q", spill)
+	}
+}
+
+func TestWriteOverflowSpillTooSmall(t *testing.T) {
+	path := setupSummaryFile(t, "")
+	spillPath := filepath.Join(t.TempDir(), "summary-overflow.html")
+
+	s := summary.NewSummary().AddRaw(strings.Repeat("1", 100), ptr(true))
+	_, err := s.Write(summary.SummaryWriteOptions{
+		Overflow:  ptr(summary.SummaryOverflowSpill),
+		SpillPath: &spillPath,
+		MaxSize:   ptr(10),
+	})
+	if !errors.Is(err, summary.ErrSummaryTooLarge) {
+		t.Fatalf("expected ErrSummaryTooLarge, got %v", err)
+	}
+	assertFile(t, path, "")
+	if _, err := os.Stat(spillPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected no spill file, got %v", err)
+	}
+}
+
+func TestMarkdownBlocks(t *testing.T) {
+	s := summary.NewSummary()
+	s.AddRaw("<h1>Report</h1>", ptr(true))
+	s.AddAlert(summary.SummaryAlertWarning, "Flaky tests detected.\n\nSee below.")
+	s.AddMermaid("graph TD\n  A-->B")
+	s.AddTaskList([]summary.SummaryTaskListItem{{Text: "build", Checked: true}, {Text: "deploy"}})
+	s.AddRaw("Done ", nil).AddEmoji("rocket").AddRaw(" "+summary.SummaryFootnoteRef("note 1"), nil)
+	s.AddFootnote("note 1", "First line\nsecond line")
+
+	expected := "<h1>Report</h1>" + eol +
+		eol +
+		"> [!WARNING]" + eol + "> Flaky tests detected." + eol + ">" + eol + "> See below." + eol +
+		eol +
+		"```mermaid" + eol + "graph TD" + eol + "  A-->B" + eol + "```" + eol +
+		eol +
+		"- [x] build" + eol + "- [ ] deploy" + eol +
+		eol +
+		"Done :rocket: [^note-1]" + eol +
+		eol +
+		"[^note-1]: First line" + eol + "    second line" + eol +
+		eol
+	if got := s.Stringify(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestAddMermaidLongerFence(t *testing.T) {
+	s := summary.NewSummary().AddMermaid("A[\"```\"]")
+	expected := "````mermaid" + eol + "A[\"```\"]" + eol + "````" + eol + eol
+	if got := s.Stringify(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	s := summary.NewSummary()
+	s.AddRaw("<h1>Report</h1>", ptr(true))
+	s.AddAlert(summary.SummaryAlertTip, "Use **caching**.")
+	s.AddTaskList([]summary.SummaryTaskListItem{{Text: "build", Checked: true}, {Text: "`deploy`"}})
+	s.AddRaw("Done "+summary.SummaryEmoji("tada")+" "+summary.SummaryFootnoteRef("a"), ptr(true))
+	s.AddFootnote("a", "Footnote.")
+
+	rendered := summary.RenderHTML(s.Stringify(), summary.SummaryRenderOptions{Title: ptr("Preview <1>")})
+	if !strings.Contains(rendered, "<title>Preview &lt;1&gt;</title>") {
+		t.Errorf("expected escaped title in %q", rendered)
+	}
+	start := strings.Index(rendered, "<main class=\"markdown-body\">\n")
+	end := strings.Index(rendered, "</main>")
+	if start < 0 || end < 0 {
+		t.Fatalf("expected a <main> element in %q", rendered)
+	}
+	body := rendered[start+len("<main class=\"markdown-body\">\n") : end]
+	expected := "<h1>Report</h1>\n" +
+		"<div class=\"markdown-alert markdown-alert-tip\">\n" +
+		"<p class=\"markdown-alert-title\">Tip</p>\n" +
+		"<p>Use <strong>caching</strong>.</p>\n" +
+		"</div>\n" +
+		"<ul>\n" +
+		"<li class=\"task-list-item\"><input type=\"checkbox\" disabled checked> build</li>\n" +
+		"<li class=\"task-list-item\"><input type=\"checkbox\" disabled> <code>deploy</code></li>\n" +
+		"</ul>\n" +
+		"<p>Done 🎉 <sup><a href=\"#fn-a\" id=\"fnref-a\">1</a></sup></p>\n" +
+		"<section class=\"footnotes\">\n<ol>\n" +
+		"<li id=\"fn-a\">Footnote. <a href=\"#fnref-a\">↩</a></li>\n" +
+		"</ol>\n</section>\n"
+	if body != expected {
+		t.Errorf("expected %q, got %q", expected, body)
+	}
+}
+
+type templateRow struct {
+	Name string `summary:"Package"`
+	Size int64  `summary:",align=right"`
+}
+
+func TestAddTemplate(t *testing.T) {
+	t.Setenv("GITHUB_SERVER_URL", "https://github.example.com")
+	t.Setenv("GITHUB_REPOSITORY", "octo/repo")
+
+	const text = `## Build {{ commit .SHA }}
+Took {{ duration .Elapsed }}, wrote {{ bytes .Written }}.
+{{ table .Rows }}
+{{ codeblock "sh" .Command }}
+`
+	data := map[string]any{
+		"SHA":     "0123456789abcdef",
+		"Elapsed": 83*time.Second + 400*time.Millisecond,
+		"Written": 1536,
+		"Rows":    []templateRow{{"a<b>", 2048}},
+		"Command": "go test ./... > out",
+	}
+	expected := "## Build <a href=\"https://github.example.com/octo/repo/commit/0123456789abcdef\"><code>0123456</code></a>" + eol +
+		"Took 1m23s, wrote 1.5 KiB." + eol +
+		"<table><tr><th>Package</th><th align=\"right\">Size</th></tr><tr><td>a&lt;b&gt;</td><td align=\"right\">2048</td></tr></table>" + eol +
+		"<pre lang=\"sh\"><code>go test ./... &gt; out</code></pre>" + eol +
+		eol
+
+	textTmpl := texttemplate.Must(texttemplate.New("report").Funcs(summary.SummaryTemplateFuncs()).Parse(text))
+	s, err := summary.NewSummary().AddTemplate(textTmpl, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Stringify(); got != expected {
+		t.Errorf("text/template: expected %q, got %q", expected, got)
+	}
+
+	htmlTmpl := htmltemplate.Must(htmltemplate.New("report").Funcs(summary.SummaryTemplateFuncs()).Parse(text))
+	s, err = summary.NewSummary().AddTemplate(htmlTmpl, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Stringify(); got != expected {
+		t.Errorf("html/template: expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,284 @@
+package summary
+
+import (
+	"cmp"
+	"fmt"
+	"html"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Format and SortBy are keyed by Go field name, not header text. Format output
+// is inserted as raw HTML; everything else is escaped. Nil values sort before
+// all others.
+type SummaryTableFromOptions struct {
+	Format     map[string]func(any) string
+	SortBy     *string
+	Descending *bool
+	Limit      *int
+}
+
+type tableColumn struct {
+	field  string
+	index  []int
+	header string
+	align  string
+}
+
+// AddTableFrom adds an HTML table built from a slice (or array) of structs or
+// struct pointers. Each exported field becomes a column unless it is tagged
+// `summary:"-"`. The tag's first element is the header text (defaulting to
+// the field name) and "align=left|center|right" sets the column alignment:
+//
+//	type Row struct {
+//		Name     string        `summary:"Test"`
+//		Duration time.Duration `summary:"Time,align=right"`
+//		internal string        // skipped
+//	}
+func (s *Summary) AddTableFrom(slice any, options SummaryTableFromOptions) (*Summary, error) {
+	v := reflect.ValueOf(slice)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("AddTableFrom: expected a slice of structs, got %T", slice)
+	}
+	elemType := v.Type().Elem()
+	if elemType.Kind() == reflect.Pointer {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("AddTableFrom: expected a slice of structs, got %T", slice)
+	}
+
+	columns, err := tableColumns(elemType)
+	if err != nil {
+		return nil, err
+	}
+	for field := range options.Format {
+		if findColumn(columns, field) == nil {
+			return nil, fmt.Errorf("AddTableFrom: Format references unknown column %q", field)
+		}
+	}
+
+	elems := make([]reflect.Value, v.Len())
+	for i := range elems {
+		elems[i] = v.Index(i)
+	}
+
+	if options.SortBy != nil {
+		column := findColumn(columns, *options.SortBy)
+		if column == nil {
+			return nil, fmt.Errorf("AddTableFrom: SortBy references unknown column %q", *options.SortBy)
+		}
+		descending := options.Descending != nil && *options.Descending
+		sort.SliceStable(elems, func(i, j int) bool {
+			a, aOK := fieldByIndex(elems[i], column.index)
+			b, bOK := fieldByIndex(elems[j], column.index)
+			var c int
+			switch {
+			case !aOK && !bOK:
+				c = 0
+			case !aOK:
+				c = -1
+			case !bOK:
+				c = 1
+			default:
+				c = compareValues(a, b)
+			}
+			if descending {
+				return c > 0
+			}
+			return c < 0
+		})
+	}
+
+	if options.Limit != nil && *options.Limit > 0 && len(elems) > *options.Limit {
+		elems = elems[:*options.Limit]
+	}
+
+	rows := make([]SummaryTableRow, 0, len(elems)+1)
+	header := make(SummaryTableRow, len(columns))
+	for i, column := range columns {
+		header[i] = columnCell(column, html.EscapeString(column.header), true)
+	}
+	rows = append(rows, header)
+	for _, elem := range elems {
+		row := make(SummaryTableRow, len(columns))
+		for i, column := range columns {
+			row[i] = columnCell(column, formatField(elem, column, options.Format[column.field]), false)
+		}
+		rows = append(rows, row)
+	}
+	return s.AddTable(rows), nil
+}
+
+func tableColumns(t reflect.Type) ([]tableColumn, error) {
+	var columns []tableColumn
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous || !promotedFromExported(t, field.Index) {
+			continue
+		}
+		tag, hasTag := field.Tag.Lookup("summary")
+		if tag == "-" {
+			continue
+		}
+		column := tableColumn{field: field.Name, index: field.Index, header: field.Name}
+		if hasTag {
+			name, opts, _ := strings.Cut(tag, ",")
+			if name != "" {
+				column.header = name
+			}
+			for _, opt := range strings.Split(opts, ",") {
+				if opt == "" {
+					continue
+				}
+				key, value, _ := strings.Cut(opt, "=")
+				switch key {
+				case "align":
+					switch value {
+					case "left", "center", "right":
+						column.align = value
+					default:
+						return nil, fmt.Errorf("AddTableFrom: field %s has invalid alignment %q", field.Name, value)
+					}
+				default:
+					return nil, fmt.Errorf("AddTableFrom: field %s has unknown summary tag option %q", field.Name, key)
+				}
+			}
+		}
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("AddTableFrom: %s has no exported fields", t)
+	}
+	return columns, nil
+}
+
+func promotedFromExported(t reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		field := t.Field(i)
+		if !field.IsExported() {
+			return false
+		}
+		t = field.Type
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+	}
+	return true
+}
+
+func findColumn(columns []tableColumn, field string) *tableColumn {
+	for i := range columns {
+		if columns[i].field == field {
+			return &columns[i]
+		}
+	}
+	return nil
+}
+
+func columnCell(column tableColumn, data string, header bool) SummaryTableCell {
+	cell := SummaryTableCell{Data: data}
+	if header {
+		cell.Header = ptr(true)
+	}
+	if column.align != "" {
+		cell.Align = ptr(column.align)
+	}
+	return cell
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex but reports false instead
+// of panicking when it has to step through a nil pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for _, i := range index {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v, true
+}
+
+func formatField(elem reflect.Value, column tableColumn, format func(any) string) string {
+	v, ok := fieldByIndex(elem, column.index)
+	if !ok {
+		return ""
+	}
+	if format != nil {
+		return format(v.Interface())
+	}
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		if _, ok := v.Interface().(fmt.Stringer); ok {
+			break
+		}
+		v = v.Elem()
+	}
+	return html.EscapeString(fmt.Sprint(v.Interface()))
+}
+
+func compareValues(a, b reflect.Value) int {
+	for a.Kind() == reflect.Pointer || a.Kind() == reflect.Interface {
+		if a.IsNil() {
+			break
+		}
+		a = a.Elem()
+	}
+	for b.Kind() == reflect.Pointer || b.Kind() == reflect.Interface {
+		if b.IsNil() {
+			break
+		}
+		b = b.Elem()
+	}
+	aNil, bNil := isNil(a), isNil(b)
+	switch {
+	case aNil && bNil:
+		return 0
+	case aNil:
+		return -1
+	case bNil:
+		return 1
+	}
+	if a.Kind() != b.Kind() {
+		return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+	}
+	if at, ok := a.Interface().(time.Time); ok {
+		if bt, ok := b.Interface().(time.Time); ok {
+			return at.Compare(bt)
+		}
+	}
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.String:
+		return strings.Compare(a.String(), b.String())
+	case reflect.Bool:
+		switch {
+		case a.Bool() == b.Bool():
+			return 0
+		case !a.Bool():
+			return -1
+		default:
+			return 1
+		}
+	case reflect.Pointer, reflect.Interface:
+		// Both nil.
+		return 0
+	default:
+		return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+	}
+}
+
+func isNil(v reflect.Value) bool {
+	return (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil()
+}
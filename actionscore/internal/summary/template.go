@@ -0,0 +1,179 @@
+package summary
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Both *text/template.Template and *html/template.Template satisfy this.
+type SummaryTemplate interface {
+	Execute(w io.Writer, data any) error
+}
+
+// AddTemplate executes tmpl with data and adds the result as its own block,
+// so templates can freely mix markdown and HTML. Parse templates with
+// SummaryTemplateFuncs to get the summary helpers:
+//
+//	//go:embed report.md.tmpl
+//	var reportTemplate string
+//
+//	tmpl := template.Must(template.New("report").Funcs(summary.SummaryTemplateFuncs()).Parse(reportTemplate))
+//	_, err := s.AddTemplate(tmpl, results)
+func (s *Summary) AddTemplate(tmpl SummaryTemplate, data any) (*Summary, error) {
+	var out bytes.Buffer
+	err := tmpl.Execute(&out, data)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimRight(out.String(), "\r\n")
+	if text == "" {
+		return s, nil
+	}
+	return s.addBlock(strings.Join(splitLines(text), eol)), nil
+}
+
+// SummaryTemplateFuncs returns the helpers available to summary templates.
+// Helpers that produce markup return html/template.HTML so html/template
+// doesn't escape them; text/template prints them as-is.
+//
+//	{{ table .Rows }}              table from a slice of structs (see AddTableFrom) or [][]string
+//	{{ codeblock "go" .Source }}   <pre lang="go"><code>...</code></pre>
+//	{{ duration .Elapsed }}        time.Duration or seconds, e.g. "1m23s"
+//	{{ bytes .Size }}              byte count, e.g. "1.5 MiB"
+//	{{ commit .SHA }}              link to the commit on GITHUB_SERVER_URL
+//	{{ commitURL .SHA }}           just the URL
+func SummaryTemplateFuncs() map[string]any {
+	return map[string]any{
+		"table":     templateTable,
+		"codeblock": templateCodeBlock,
+		"duration":  templateDuration,
+		"bytes":     templateBytes,
+		"commit":    templateCommit,
+		"commitURL": templateCommitURL,
+	}
+}
+
+func templateTable(rows any) (htmltemplate.HTML, error) {
+	s := NewSummary()
+	switch rows := rows.(type) {
+	case []SummaryTableRow:
+		s.AddTable(rows)
+	case [][]string:
+		tableRows := make([]SummaryTableRow, len(rows))
+		for i, row := range rows {
+			tableRow := make(SummaryTableRow, len(row))
+			for j, cell := range row {
+				cell = html.EscapeString(cell)
+				if i == 0 {
+					tableRow[j] = SummaryTableCell{Data: cell, Header: ptr(true)}
+				} else {
+					tableRow[j] = cell
+				}
+			}
+			tableRows[i] = tableRow
+		}
+		s.AddTable(tableRows)
+	default:
+		_, err := s.AddTableFrom(rows, SummaryTableFromOptions{})
+		if err != nil {
+			return "", err
+		}
+	}
+	return htmltemplate.HTML(strings.TrimSuffix(s.Stringify(), eol)), nil
+}
+
+func templateCodeBlock(lang string, code string) htmltemplate.HTML {
+	var attrs string
+	if lang != "" {
+		attrs = fmt.Sprintf(` lang="%s"`, html.EscapeString(lang))
+	}
+	return htmltemplate.HTML(fmt.Sprintf("<pre%s><code>%s</code></pre>", attrs, html.EscapeString(code)))
+}
+
+func toFloat(v any) (float64, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	default:
+		return 0, fmt.Errorf("expected a number, got %T", v)
+	}
+}
+
+func templateDuration(v any) (string, error) {
+	var d time.Duration
+	switch v := v.(type) {
+	case time.Duration:
+		d = v
+	default:
+		seconds, err := toFloat(v)
+		if err != nil {
+			return "", fmt.Errorf("duration: %w", err)
+		}
+		d = time.Duration(seconds * float64(time.Second))
+	}
+	switch {
+	case d < time.Second && d > -time.Second:
+		return d.Round(time.Millisecond).String(), nil
+	case d < time.Minute && d > -time.Minute:
+		return d.Round(100 * time.Millisecond).String(), nil
+	default:
+		return d.Round(time.Second).String(), nil
+	}
+}
+
+func templateBytes(v any) (string, error) {
+	n, err := toFloat(v)
+	if err != nil {
+		return "", fmt.Errorf("bytes: %w", err)
+	}
+	const unit = 1024
+	if n < unit && n > -unit {
+		return fmt.Sprintf("%.0f B", n), nil
+	}
+	exp := 0
+	for abs := n / unit; (abs >= unit || abs <= -unit) && exp < 5; abs /= unit {
+		exp++
+	}
+	div := 1.0
+	for i := 0; i <= exp; i++ {
+		div *= unit
+	}
+	return fmt.Sprintf("%.1f %ciB", n/div, "KMGTPE"[exp]), nil
+}
+
+func templateCommitURL(sha string) string {
+	serverURL := os.Getenv("GITHUB_SERVER_URL")
+	if serverURL == "" {
+		serverURL = "https://github.com"
+	}
+	repository := os.Getenv("GITHUB_REPOSITORY")
+	if repository == "" {
+		return ""
+	}
+	return strings.TrimSuffix(serverURL, "/") + "/" + repository + "/commit/" + sha
+}
+
+func templateCommit(sha string) htmltemplate.HTML {
+	short := sha
+	if len(short) > 7 {
+		short = short[:7]
+	}
+	code := "<code>" + html.EscapeString(short) + "</code>"
+	commitURL := templateCommitURL(sha)
+	if commitURL == "" {
+		return htmltemplate.HTML(code)
+	}
+	return htmltemplate.HTML(fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(commitURL), code))
+}
@@ -15,6 +15,9 @@ func ToCommandValue(input any) (string, error) {
 			return "", nil
 		}
 	}
+	if reflect.TypeOf(input).Kind() == reflect.Pointer {
+		return ToCommandValue(reflect.ValueOf(input).Elem().Interface())
+	}
 	if v, ok := input.(string); ok {
 		return v, nil
 	}
@@ -48,11 +51,11 @@ func ToCommandProperties(annotationProperties InternalCoreAnnotationProperties)
 	}
 
 	return commandCommandProperties{
-		"title":       annotationProperties.Title,
-		"file":        annotationProperties.File,
-		"startLine":   annotationProperties.StartLine,
-		"endLine":     annotationProperties.EndLine,
-		"startColumn": annotationProperties.StartColumn,
-		"endColumn":   annotationProperties.EndColumn,
+		"title":     annotationProperties.Title,
+		"file":      annotationProperties.File,
+		"line":      annotationProperties.StartLine,
+		"endLine":   annotationProperties.EndLine,
+		"col":       annotationProperties.StartColumn,
+		"endColumn": annotationProperties.EndColumn,
 	}
 }
@@ -0,0 +1,55 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/jcbhmr/go-toolkit/actionscore/internal/utils"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestToCommandValue(t *testing.T) {
+	var nilString *string
+	for _, test := range []struct {
+		input    any
+		expected string
+	}{
+		{nil, ""},
+		{"hello", "hello"},
+		{ptr("hello"), "hello"},
+		{nilString, ""},
+		{ptr(42), "42"},
+		{map[string]int{"a": 1}, `{"a":1}`},
+		{ptr(struct{ A int }{1}), `{"A":1}`},
+	} {
+		got, err := utils.ToCommandValue(test.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, got)
+		}
+	}
+}
+
+func TestToCommandProperties(t *testing.T) {
+	properties := utils.ToCommandProperties(utils.InternalCoreAnnotationProperties{
+		File:        ptr("main.go"),
+		StartLine:   ptr("3"),
+		StartColumn: ptr("5"),
+	})
+	for key, expected := range map[string]string{"file": "main.go", "line": "3", "col": "5", "title": "", "endLine": ""} {
+		got, err := utils.ToCommandValue(properties[key])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != expected {
+			t.Errorf("expected %s=%q, got %q", key, expected, got)
+		}
+	}
+	if _, ok := properties["startLine"]; ok {
+		t.Errorf("expected the runner's line/col names, got %v", properties)
+	}
+}
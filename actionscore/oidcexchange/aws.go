@@ -0,0 +1,115 @@
+package oidcexchange
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AWSProvider assumes an IAM role with STS AssumeRoleWithWebIdentity.
+type AWSProvider struct {
+	RoleARN string
+	// Region is exported as AWS_REGION and picks the regional STS endpoint.
+	Region string
+	// Defaults to GitHubActions-<run id>.
+	RoleSessionName *string
+	// Defaults to one hour.
+	Duration *time.Duration
+	// Defaults to "sts.amazonaws.com".
+	IDTokenAudience *string
+	// Defaults to https://sts.<region>.amazonaws.com, or the global endpoint
+	// without a region.
+	Endpoint   *string
+	HTTPClient *http.Client
+}
+
+func (p *AWSProvider) Audience() string {
+	return ptrOr(p.IDTokenAudience, "sts.amazonaws.com")
+}
+
+type awsCredentials struct {
+	AccessKeyID     string    `xml:"AccessKeyId"`
+	SecretAccessKey string    `xml:"SecretAccessKey"`
+	SessionToken    string    `xml:"SessionToken"`
+	Expiration      time.Time `xml:"Expiration"`
+}
+
+func (p *AWSProvider) Exchange(ctx context.Context, idToken string) (*Credentials, error) {
+	endpoint := "https://sts.amazonaws.com"
+	if p.Region != "" {
+		endpoint = "https://sts." + p.Region + ".amazonaws.com"
+	}
+	endpoint = ptrOr(p.Endpoint, endpoint)
+	sessionName := "GitHubActions"
+	if runID := os.Getenv("GITHUB_RUN_ID"); runID != "" {
+		sessionName += "-" + runID
+	}
+	sessionName = ptrOr(p.RoleSessionName, sessionName)
+
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {p.RoleARN},
+		"RoleSessionName":  {sessionName},
+		"WebIdentityToken": {idToken},
+		"DurationSeconds":  {strconv.Itoa(int(ptrOr(p.Duration, time.Hour) / time.Second))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/xml")
+	body, err := do(p.HTTPClient, req, awsError)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Result struct {
+			Credentials awsCredentials `xml:"Credentials"`
+		} `xml:"AssumeRoleWithWebIdentityResult"`
+	}
+	err = xml.Unmarshal(body, &response)
+	if err != nil {
+		return nil, fmt.Errorf("AssumeRoleWithWebIdentity: %w", err)
+	}
+	creds := response.Result.Credentials
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, fmt.Errorf("AssumeRoleWithWebIdentity: response has no credentials")
+	}
+
+	env := map[string]string{
+		"AWS_ACCESS_KEY_ID":     creds.AccessKeyID,
+		"AWS_SECRET_ACCESS_KEY": creds.SecretAccessKey,
+		"AWS_SESSION_TOKEN":     creds.SessionToken,
+	}
+	if p.Region != "" {
+		env["AWS_REGION"] = p.Region
+		env["AWS_DEFAULT_REGION"] = p.Region
+	}
+	return &Credentials{
+		Env:     env,
+		Secrets: []string{creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken},
+		Expiry:  creds.Expiration,
+	}, nil
+}
+
+func awsError(status int, body []byte) error {
+	var response struct {
+		Error struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		} `xml:"Error"`
+	}
+	if xml.Unmarshal(body, &response) == nil && response.Error.Code != "" {
+		return fmt.Errorf("AssumeRoleWithWebIdentity: HTTP %d: %s: %s", status, response.Error.Code, response.Error.Message)
+	}
+	return fmt.Errorf("AssumeRoleWithWebIdentity: HTTP %d: %s", status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,117 @@
+package oidcexchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// AzureProvider signs in to Microsoft Entra ID as an app registration or
+// managed identity with a federated credential, using the ID token as the
+// client assertion.
+//
+// The Azure SDKs and the Azure CLI don't take an access token from the
+// environment, so the ID token is written to a file under RUNNER_TEMP and
+// exported as AZURE_FEDERATED_TOKEN_FILE, which their workload identity
+// credentials read along with AZURE_CLIENT_ID and AZURE_TENANT_ID. The ID
+// token expires after a few minutes, so later steps must sign in before it
+// does. The access token itself is only returned in Credentials.AccessToken.
+type AzureProvider struct {
+	TenantID string
+	ClientID string
+	// Defaults to https://management.azure.com/.default.
+	Scope *string
+	// Defaults to "api://AzureADTokenExchange".
+	IDTokenAudience *string
+	// Defaults to https://login.microsoftonline.com.
+	AuthorityHost *string
+	HTTPClient    *http.Client
+}
+
+func (p *AzureProvider) Audience() string {
+	return ptrOr(p.IDTokenAudience, "api://AzureADTokenExchange")
+}
+
+func (p *AzureProvider) Exchange(ctx context.Context, idToken string) (*Credentials, error) {
+	endpoint := strings.TrimSuffix(ptrOr(p.AuthorityHost, "https://login.microsoftonline.com"), "/") +
+		"/" + url.PathEscape(p.TenantID) + "/oauth2/v2.0/token"
+	form := url.Values{
+		"client_id":             {p.ClientID},
+		"scope":                 {ptrOr(p.Scope, "https://management.azure.com/.default")},
+		"grant_type":            {"client_credentials"},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {idToken},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	body, err := do(p.HTTPClient, req, oauthError("Azure token request"))
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, fmt.Errorf("Azure token request: %w", err)
+	}
+	if response.AccessToken == "" {
+		return nil, fmt.Errorf("Azure token request: response has no access_token")
+	}
+	tokenFile, err := writeFederatedToken(idToken)
+	if err != nil {
+		return nil, fmt.Errorf("Azure token request: %w", err)
+	}
+	env := map[string]string{
+		"AZURE_CLIENT_ID":            p.ClientID,
+		"AZURE_TENANT_ID":            p.TenantID,
+		"AZURE_FEDERATED_TOKEN_FILE": tokenFile,
+	}
+	if p.AuthorityHost != nil {
+		env["AZURE_AUTHORITY_HOST"] = *p.AuthorityHost
+	}
+	var expiry time.Time
+	if response.ExpiresIn > 0 {
+		expiry = time.Now().Add(time.Duration(response.ExpiresIn) * time.Second)
+	}
+	return &Credentials{
+		Env:         env,
+		AccessToken: response.AccessToken,
+		Secrets:     []string{response.AccessToken},
+		Expiry:      expiry,
+	}, nil
+}
+
+// writeFederatedToken writes idToken to a file only the current user can
+// read, in RUNNER_TEMP so the runner deletes it after the job.
+func writeFederatedToken(idToken string) (string, error) {
+	dir := os.Getenv("RUNNER_TEMP")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	f, err := os.CreateTemp(dir, "azure-federated-token-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.WriteString(idToken)
+	if err != nil {
+		f.Close()
+		return "", err
+	}
+	err = f.Close()
+	if err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
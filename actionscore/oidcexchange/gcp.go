@@ -0,0 +1,141 @@
+package oidcexchange
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GCPProvider uses workload identity federation: the ID token is exchanged
+// for a federated access token with the Security Token Service, which is then
+// used to impersonate ServiceAccount if it is set.
+type GCPProvider struct {
+	// The full resource name, e.g.
+	// projects/123456789/locations/global/workloadIdentityPools/github/providers/my-repo.
+	WorkloadIdentityProvider string
+	// The email of the service account to impersonate. Without one the
+	// federated token is used directly.
+	ServiceAccount *string
+	// Defaults to https://www.googleapis.com/auth/cloud-platform.
+	Scopes []string
+	// Lifetime of the service account token. Defaults to one hour.
+	Lifetime *time.Duration
+	// Defaults to https://iam.googleapis.com/<WorkloadIdentityProvider>.
+	IDTokenAudience *string
+	// Defaults to https://sts.googleapis.com/v1/token.
+	STSEndpoint *string
+	// Defaults to https://iamcredentials.googleapis.com/v1.
+	IAMCredentialsEndpoint *string
+	HTTPClient             *http.Client
+}
+
+func (p *GCPProvider) Audience() string {
+	return ptrOr(p.IDTokenAudience, "https://iam.googleapis.com/"+strings.TrimPrefix(p.WorkloadIdentityProvider, "/"))
+}
+
+func (p *GCPProvider) Exchange(ctx context.Context, idToken string) (*Credentials, error) {
+	scopes := p.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"https://www.googleapis.com/auth/cloud-platform"}
+	}
+
+	var sts struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	err := postJSON(ctx, p.HTTPClient, ptrOr(p.STSEndpoint, "https://sts.googleapis.com/v1/token"), "", map[string]any{
+		"audience":           "//iam.googleapis.com/" + strings.TrimPrefix(p.WorkloadIdentityProvider, "/"),
+		"grantType":          "urn:ietf:params:oauth:grant-type:token-exchange",
+		"requestedTokenType": "urn:ietf:params:oauth:token-type:access_token",
+		"scope":              strings.Join(scopes, " "),
+		"subjectTokenType":   "urn:ietf:params:oauth:token-type:jwt",
+		"subjectToken":       idToken,
+	}, oauthError("GCP STS token exchange"), &sts)
+	if err != nil {
+		return nil, err
+	}
+	if sts.AccessToken == "" {
+		return nil, fmt.Errorf("GCP STS token exchange: response has no access_token")
+	}
+	accessToken := sts.AccessToken
+	var expiry time.Time
+	if sts.ExpiresIn > 0 {
+		expiry = time.Now().Add(time.Duration(sts.ExpiresIn) * time.Second)
+	}
+	secrets := []string{sts.AccessToken}
+
+	if p.ServiceAccount != nil {
+		endpoint := strings.TrimSuffix(ptrOr(p.IAMCredentialsEndpoint, "https://iamcredentials.googleapis.com/v1"), "/") +
+			"/projects/-/serviceAccounts/" + url.PathEscape(*p.ServiceAccount) + ":generateAccessToken"
+		var impersonated struct {
+			AccessToken string    `json:"accessToken"`
+			ExpireTime  time.Time `json:"expireTime"`
+		}
+		err := postJSON(ctx, p.HTTPClient, endpoint, sts.AccessToken, map[string]any{
+			"scope":    scopes,
+			"lifetime": fmt.Sprintf("%ds", int(ptrOr(p.Lifetime, time.Hour)/time.Second)),
+		}, googleError("GCP service account impersonation"), &impersonated)
+		if err != nil {
+			return nil, err
+		}
+		if impersonated.AccessToken == "" {
+			return nil, fmt.Errorf("GCP service account impersonation: response has no accessToken")
+		}
+		accessToken = impersonated.AccessToken
+		expiry = impersonated.ExpireTime
+		secrets = append(secrets, impersonated.AccessToken)
+	}
+
+	return &Credentials{
+		Env: map[string]string{
+			"CLOUDSDK_AUTH_ACCESS_TOKEN": accessToken,
+			"GOOGLE_OAUTH_ACCESS_TOKEN":  accessToken,
+		},
+		AccessToken: accessToken,
+		Secrets:     secrets,
+		Expiry:      expiry,
+	}, nil
+}
+
+func postJSON(ctx context.Context, client *http.Client, endpoint string, bearer string, body any, describe func(int, []byte) error, v any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	response, err := do(client, req, describe)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(response, v)
+}
+
+// googleError describes the {"error": {"code", "message", "status"}} errors
+// of Google APIs.
+func googleError(what string) func(status int, body []byte) error {
+	return func(status int, body []byte) error {
+		var response struct {
+			Error struct {
+				Message string `json:"message"`
+				Status  string `json:"status"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(body, &response) == nil && response.Error.Message != "" {
+			return fmt.Errorf("%s: HTTP %d: %s: %s", what, status, response.Error.Status, response.Error.Message)
+		}
+		return fmt.Errorf("%s: HTTP %d: %s", what, status, strings.TrimSpace(string(body)))
+	}
+}
@@ -0,0 +1,137 @@
+// Package oidcexchange trades the GitHub Actions OIDC ID token of a job for
+// short-lived cloud credentials.
+//
+//	creds, err := oidcexchange.Exchange(ctx, &oidcexchange.AWSProvider{
+//		RoleARN: "arn:aws:iam::123456789012:role/deploy",
+//		Region:  "us-east-1",
+//	}, oidcexchange.Options{})
+//
+// The credentials are masked and, unless disabled, exported as the
+// environment variables the cloud's SDKs and CLIs read, so later steps pick
+// them up. The job needs the id-token: write permission.
+package oidcexchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	core "github.com/jcbhmr/go-toolkit/actionscore"
+)
+
+// Provider exchanges an ID token for credentials with a cloud.
+type Provider interface {
+	// Audience is the audience to request the ID token for.
+	Audience() string
+	Exchange(ctx context.Context, idToken string) (*Credentials, error)
+}
+
+type Credentials struct {
+	// Env holds the credentials as the environment variables the cloud's
+	// tools read, e.g. AWS_ACCESS_KEY_ID.
+	Env map[string]string
+	// AccessToken is the bearer token for calling the cloud's APIs directly,
+	// if the provider ends with one. AWS credentials have none.
+	AccessToken string
+	// Secrets are the values that must be masked in logs.
+	Secrets []string
+	// Expiry is when the credentials expire, or zero if unknown.
+	Expiry time.Time
+}
+
+type Options struct {
+	// Export sets Env with core.ExportVariable. Defaults to true.
+	Export *bool
+}
+
+// Exchange gets an ID token for the provider's audience from
+// core.IDTokenSource, exchanges it and masks (and by default exports) the
+// resulting credentials.
+func Exchange(ctx context.Context, provider Provider, options Options) (*Credentials, error) {
+	audience := provider.Audience()
+	idToken, err := core.IDTokenSource(ctx, &audience).Token()
+	if err != nil {
+		return nil, err
+	}
+	creds, err := provider.Exchange(ctx, idToken.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	for _, secret := range creds.Secrets {
+		if secret == "" {
+			continue
+		}
+		err := core.SetSecret(secret)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if options.Export == nil || *options.Export {
+		names := make([]string, 0, len(creds.Env))
+		for name := range creds.Env {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			err := core.ExportVariable(name, creds.Env[name])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return creds, nil
+}
+
+func httpClient(client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+	return http.DefaultClient
+}
+
+// do sends req and returns the body of a 2xx response, or an error built
+// from the response with describe.
+func do(client *http.Client, req *http.Request, describe func(status int, body []byte) error) ([]byte, error) {
+	res, err := httpClient(client).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, describe(res.StatusCode, body)
+	}
+	return body, nil
+}
+
+// oauthError describes the error responses of OAuth 2.0 token endpoints.
+func oauthError(what string) func(status int, body []byte) error {
+	return func(status int, body []byte) error {
+		var response struct {
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &response) == nil && response.Error != "" {
+			if response.ErrorDescription != "" {
+				return fmt.Errorf("%s: HTTP %d: %s: %s", what, status, response.Error, response.ErrorDescription)
+			}
+			return fmt.Errorf("%s: HTTP %d: %s", what, status, response.Error)
+		}
+		return fmt.Errorf("%s: HTTP %d: %s", what, status, strings.TrimSpace(string(body)))
+	}
+}
+
+func ptrOr[T any](p *T, fallback T) T {
+	if p != nil {
+		return *p
+	}
+	return fallback
+}
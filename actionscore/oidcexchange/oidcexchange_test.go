@@ -0,0 +1,204 @@
+package oidcexchange_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jcbhmr/go-toolkit/actionscore/actionstest"
+	"github.com/jcbhmr/go-toolkit/actionscore/oidcexchange"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// fakeCloud serves the runner's ID token endpoint and fake AWS, GCP and Azure
+// token endpoints.
+func fakeCloud(t *testing.T) (*httptest.Server, *actionstest.Harness) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /idtoken", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"value": "id-token-for-" + r.URL.Query().Get("audience")})
+	})
+	mux.HandleFunc("POST /aws", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("Action") != "AssumeRoleWithWebIdentity" || r.Form.Get("WebIdentityToken") != "id-token-for-sts.amazonaws.com" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`<ErrorResponse><Error><Code>InvalidIdentityToken</Code><Message>bad token</Message></Error></ErrorResponse>`))
+			return
+		}
+		if r.Form.Get("RoleArn") != "arn:aws:iam::123456789012:role/deploy" || r.Form.Get("RoleSessionName") != "GitHubActions-42" || r.Form.Get("DurationSeconds") != "900" {
+			t.Errorf("unexpected AWS request %v", r.Form)
+		}
+		w.Write([]byte(`<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>
+			<AccessKeyId>ASIAEXAMPLE</AccessKeyId><SecretAccessKey>aws-secret</SecretAccessKey>
+			<SessionToken>aws-session</SessionToken><Expiration>2030-01-01T00:00:00Z</Expiration>
+		</Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`))
+	})
+	mux.HandleFunc("POST /gcp/sts", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["subjectToken"] != "id-token-for-https://iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/p/providers/gh" || body["audience"] != "//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/p/providers/gh" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"bad subject token"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"federated-token","expires_in":3600,"token_type":"Bearer"}`))
+	})
+	mux.HandleFunc("POST /gcp/iam/projects/-/serviceAccounts/{account}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("account") != "deploy@proj.iam.gserviceaccount.com:generateAccessToken" || r.Header.Get("Authorization") != "Bearer federated-token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":{"code":403,"message":"Permission denied","status":"PERMISSION_DENIED"}}`))
+			return
+		}
+		w.Write([]byte(`{"accessToken":"sa-token","expireTime":"2030-01-01T00:00:00Z"}`))
+	})
+	mux.HandleFunc("POST /azure/{tenant}/oauth2/v2.0/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PathValue("tenant") != "tenant-id" || r.Form.Get("client_assertion") != "id-token-for-api://AzureADTokenExchange" || r.Form.Get("client_id") != "client-id" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client","error_description":"AADSTS700016"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"azure-token","expires_in":3599,"token_type":"Bearer"}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	h := actionstest.New(t, actionstest.Options{Env: map[string]string{
+		"ACTIONS_ID_TOKEN_REQUEST_URL":   server.URL + "/idtoken",
+		"ACTIONS_ID_TOKEN_REQUEST_TOKEN": "request-token",
+		"GITHUB_RUN_ID":                  "42",
+		"RUNNER_TEMP":                    t.TempDir(),
+	}})
+	return server, h
+}
+
+func TestAWS(t *testing.T) {
+	server, h := fakeCloud(t)
+	creds, err := oidcexchange.Exchange(context.Background(), &oidcexchange.AWSProvider{
+		RoleARN:  "arn:aws:iam::123456789012:role/deploy",
+		Region:   "eu-west-1",
+		Duration: ptr(15 * time.Minute),
+		Endpoint: ptr(server.URL + "/aws"),
+	}, oidcexchange.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !creds.Expiry.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected expiry %s", creds.Expiry)
+	}
+	h.AssertExportedVar("AWS_ACCESS_KEY_ID", "ASIAEXAMPLE")
+	h.AssertExportedVar("AWS_SECRET_ACCESS_KEY", "aws-secret")
+	h.AssertExportedVar("AWS_SESSION_TOKEN", "aws-session")
+	h.AssertExportedVar("AWS_REGION", "eu-west-1")
+	h.AssertMasked("aws-secret")
+	h.AssertMasked("aws-session")
+	h.AssertMasked("id-token-for-sts.amazonaws.com")
+}
+
+func TestAWSError(t *testing.T) {
+	server, _ := fakeCloud(t)
+	_, err := oidcexchange.Exchange(context.Background(), &oidcexchange.AWSProvider{
+		RoleARN:         "arn:aws:iam::123456789012:role/deploy",
+		IDTokenAudience: ptr("wrong"),
+		Endpoint:        ptr(server.URL + "/aws"),
+	}, oidcexchange.Options{})
+	if err == nil || !strings.Contains(err.Error(), "InvalidIdentityToken: bad token") {
+		t.Errorf("expected an InvalidIdentityToken error, got %v", err)
+	}
+}
+
+func TestGCP(t *testing.T) {
+	server, h := fakeCloud(t)
+	provider := &oidcexchange.GCPProvider{
+		WorkloadIdentityProvider: "projects/1/locations/global/workloadIdentityPools/p/providers/gh",
+		STSEndpoint:              ptr(server.URL + "/gcp/sts"),
+		IAMCredentialsEndpoint:   ptr(server.URL + "/gcp/iam"),
+	}
+
+	creds, err := oidcexchange.Exchange(context.Background(), provider, oidcexchange.Options{Export: ptr(false)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.Env["GOOGLE_OAUTH_ACCESS_TOKEN"] != "federated-token" {
+		t.Errorf("expected the federated token, got %q", creds.Env["GOOGLE_OAUTH_ACCESS_TOKEN"])
+	}
+	if len(h.ExportedVars()) != 0 {
+		t.Errorf("expected nothing exported, got %v", h.ExportedVars())
+	}
+	h.AssertMasked("federated-token")
+
+	provider.ServiceAccount = ptr("deploy@proj.iam.gserviceaccount.com")
+	creds, err = oidcexchange.Exchange(context.Background(), provider, oidcexchange.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !creds.Expiry.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected expiry %s", creds.Expiry)
+	}
+	h.AssertExportedVar("CLOUDSDK_AUTH_ACCESS_TOKEN", "sa-token")
+	h.AssertMasked("sa-token")
+
+	if creds.AccessToken != "sa-token" {
+		t.Errorf("expected the service account token, got %q", creds.AccessToken)
+	}
+
+	// The account is a single path segment, even with a slash in it.
+	for _, account := range []string{"other@proj.iam.gserviceaccount.com", "../other@proj.iam.gserviceaccount.com"} {
+		provider.ServiceAccount = ptr(account)
+		_, err = oidcexchange.Exchange(context.Background(), provider, oidcexchange.Options{})
+		if err == nil || !strings.Contains(err.Error(), "PERMISSION_DENIED") {
+			t.Errorf("%s: expected a PERMISSION_DENIED error, got %v", account, err)
+		}
+	}
+}
+
+func TestAzure(t *testing.T) {
+	server, h := fakeCloud(t)
+	provider := &oidcexchange.AzureProvider{
+		TenantID:      "tenant-id",
+		ClientID:      "client-id",
+		AuthorityHost: ptr(server.URL + "/azure"),
+	}
+	creds, err := oidcexchange.Exchange(context.Background(), provider, oidcexchange.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.Expiry.IsZero() {
+		t.Error("expected an expiry")
+	}
+	if creds.AccessToken != "azure-token" {
+		t.Errorf("expected the access token, got %q", creds.AccessToken)
+	}
+	h.AssertExportedVar("AZURE_CLIENT_ID", "client-id")
+	h.AssertExportedVar("AZURE_TENANT_ID", "tenant-id")
+	h.AssertExportedVar("AZURE_AUTHORITY_HOST", server.URL+"/azure")
+	h.AssertMasked("azure-token")
+	if _, ok := h.ExportedVars()["AZURE_ACCESS_TOKEN"]; ok {
+		t.Error("expected the access token not to be exported")
+	}
+	tokenFile := h.ExportedVars()["AZURE_FEDERATED_TOKEN_FILE"]
+	if filepath.Dir(tokenFile) != os.Getenv("RUNNER_TEMP") {
+		t.Errorf("expected the token file in RUNNER_TEMP, got %q", tokenFile)
+	}
+	data, err := os.ReadFile(tokenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "id-token-for-api://AzureADTokenExchange" {
+		t.Errorf("expected the ID token in the token file, got %q", data)
+	}
+
+	provider.ClientID = "wrong"
+	_, err = oidcexchange.Exchange(context.Background(), provider, oidcexchange.Options{})
+	if err == nil || !strings.Contains(err.Error(), "invalid_client: AADSTS700016") {
+		t.Errorf("expected an invalid_client error, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	internalsummary "github.com/jcbhmr/go-toolkit/actionscore/internal/summary"
+)
+
+// The environment variable the runner puts the job summary file's path in,
+// and the documentation on job summaries.
+const (
+	SummaryEnvVar  = internalsummary.SummaryEnvVar
+	SummaryDocsURL = internalsummary.SummaryDocsURL
+)
+
+// SummaryMaxSize is the largest job summary GitHub renders. Write fails with
+// ErrSummaryTooLarge rather than go past it, unless the write options allow
+// truncating the summary.
+const SummaryMaxSize = internalsummary.SummaryMaxSize
+
+var ErrSummaryTooLarge = internalsummary.ErrSummaryTooLarge
+
+type SummaryOverflowPolicy = internalsummary.SummaryOverflowPolicy
+
+const (
+	SummaryOverflowError    = internalsummary.SummaryOverflowError
+	SummaryOverflowTruncate = internalsummary.SummaryOverflowTruncate
+	SummaryOverflowSpill    = internalsummary.SummaryOverflowSpill
+)
+
+// Summary buffers the HTML and markdown of a job summary until Write adds it
+// to the summary file.
+type Summary = internalsummary.Summary
+type SummaryTableRow = internalsummary.SummaryTableRow
+type SummaryTableCell = internalsummary.SummaryTableCell
+type SummaryImageOptions = internalsummary.SummaryImageOptions
+type SummaryWriteOptions = internalsummary.SummaryWriteOptions
+type SummaryTableFromOptions = internalsummary.SummaryTableFromOptions
+
+// NewSummary returns an empty summary.
+func NewSummary() *Summary {
+	return internalsummary.NewSummary()
+}
+
+type SummaryAlertType = internalsummary.SummaryAlertType
+
+const (
+	SummaryAlertNote      = internalsummary.SummaryAlertNote
+	SummaryAlertTip       = internalsummary.SummaryAlertTip
+	SummaryAlertImportant = internalsummary.SummaryAlertImportant
+	SummaryAlertWarning   = internalsummary.SummaryAlertWarning
+	SummaryAlertCaution   = internalsummary.SummaryAlertCaution
+)
+
+type SummaryTaskListItem = internalsummary.SummaryTaskListItem
+
+// SummaryFootnoteRef returns the `[^label]` reference to a footnote added
+// with Summary.AddFootnote.
+func SummaryFootnoteRef(label string) string {
+	return internalsummary.SummaryFootnoteRef(label)
+}
+
+// SummaryEmoji returns the `:shortcode:` for an emoji such as "rocket".
+func SummaryEmoji(shortcode string) string {
+	return internalsummary.SummaryEmoji(shortcode)
+}
+
+type SummaryRenderOptions = internalsummary.SummaryRenderOptions
+
+// RenderSummaryHTML renders the contents of a job summary as a standalone HTML
+// page, roughly as GitHub shows it, for previewing it locally.
+func RenderSummaryHTML(summary string, options SummaryRenderOptions) string {
+	return internalsummary.RenderHTML(summary, options)
+}
+
+// SummaryTemplate is satisfied by both *text/template.Template and
+// *html/template.Template.
+type SummaryTemplate = internalsummary.SummaryTemplate
+
+// SummaryTemplateFuncs returns the helpers available to templates passed to
+// Summary.AddTemplate: table, codeblock, duration, bytes, commit and
+// commitURL.
+func SummaryTemplateFuncs() map[string]any {
+	return internalsummary.SummaryTemplateFuncs()
+}
@@ -0,0 +1,129 @@
+package core_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	core "github.com/jcbhmr/go-toolkit/actionscore"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// summaryFile points GITHUB_STEP_SUMMARY at an empty file and returns its
+// path.
+func summaryFile(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "step_summary")
+	err := os.WriteFile(path, nil, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chmod(path, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(core.SummaryEnvVar, path)
+	return path
+}
+
+func TestSummary(t *testing.T) {
+	path := summaryFile(t)
+
+	type result struct {
+		Name   string `summary:"Test"`
+		Passed bool
+	}
+	s := core.NewSummary()
+	s.AddTable([]core.SummaryTableRow{
+		{core.SummaryTableCell{Data: "Job", Header: ptr(true)}},
+		{"build"},
+	})
+	_, err := s.AddTableFrom([]result{{"TestB", false}, {"TestA", true}}, core.SummaryTableFromOptions{SortBy: ptr("Name")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Write(core.SummaryWriteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"<tr><th>Job</th></tr><tr><td>build</td></tr>",
+		"<tr><th>Test</th><th>Passed</th></tr><tr><td>TestA</td><td>true</td></tr><tr><td>TestB</td><td>false</td></tr>",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("expected %q to contain %q", content, expected)
+		}
+	}
+	if !s.IsEmptyBuffer() {
+		t.Error("expected Write to empty the buffer")
+	}
+}
+
+func TestSummaryOverflow(t *testing.T) {
+	path := summaryFile(t)
+
+	s := core.NewSummary()
+	for i := 0; i < 10; i++ {
+		s.AddRaw("line", ptr(true))
+	}
+	_, err := s.Write(core.SummaryWriteOptions{MaxSize: ptr(10)})
+	if !errors.Is(err, core.ErrSummaryTooLarge) {
+		t.Fatalf("expected ErrSummaryTooLarge, got %v", err)
+	}
+	_, err = s.Write(core.SummaryWriteOptions{Overflow: ptr(core.SummaryOverflowTruncate), MaxSize: ptr(40)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content) > 40 || !strings.Contains(string(content), "rows omitted") {
+		t.Errorf("expected a truncated summary, got %q", content)
+	}
+}
+
+func TestSummaryMarkdown(t *testing.T) {
+	s := core.NewSummary()
+	s.AddAlert(core.SummaryAlertWarning, "Flaky tests detected.")
+	s.AddTaskList([]core.SummaryTaskListItem{{Text: "build", Checked: true}})
+	s.AddRaw("Done "+core.SummaryEmoji("rocket")+" "+core.SummaryFootnoteRef("a"), ptr(true))
+	s.AddFootnote("a", "Footnote.")
+	for _, expected := range []string{"> [!WARNING]", "- [x] build", "Done :rocket: [^a]", "[^a]: Footnote."} {
+		if !strings.Contains(s.Stringify(), expected) {
+			t.Errorf("expected %q to contain %q", s.Stringify(), expected)
+		}
+	}
+}
+
+func TestRenderSummaryHTML(t *testing.T) {
+	s := core.NewSummary()
+	s.AddAlert(core.SummaryAlertNote, "Hello")
+	html := core.RenderSummaryHTML(s.Stringify(), core.SummaryRenderOptions{Title: ptr("Preview")})
+	for _, expected := range []string{"<title>Preview</title>", "Hello"} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("expected %q to contain %q", html, expected)
+		}
+	}
+}
+
+func TestSummaryTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("report").Funcs(core.SummaryTemplateFuncs()).Parse("Wrote {{ bytes .Written }}."))
+	s, err := core.NewSummary().AddTemplate(tmpl, map[string]any{"Written": 1536})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(s.Stringify(), "Wrote 1.5 KiB.") {
+		t.Errorf("expected %q to contain %q", s.Stringify(), "Wrote 1.5 KiB.")
+	}
+}
@@ -0,0 +1,268 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token is an access token for the API.
+type Token struct {
+	AccessToken string
+	// The zero time means the token doesn't expire.
+	Expiry time.Time
+	// For installation tokens, the permissions and repository selection
+	// ("all" or "selected") the token was granted.
+	Permissions         map[string]string
+	RepositorySelection string
+}
+
+// TokenSource returns a valid token each time it is called, refreshing it as
+// needed. Set ClientOptions.TokenSource to use one for a client.
+type TokenSource interface {
+	Token() (*Token, error)
+}
+
+// DefaultAppTokenExpiryDelta is how long before it expires an installation
+// token is replaced.
+const DefaultAppTokenExpiryDelta = 5 * time.Minute
+
+// appTokenTimeout bounds the requests of one refresh. Token takes no
+// context, so this keeps a stalled API from blocking its callers forever.
+const appTokenTimeout = time.Minute
+
+type AppTokenOptions struct {
+	// The owner of the installation. Defaults to the owner of
+	// GITHUB_REPOSITORY.
+	Owner *string
+	// The repositories of Owner the token is scoped to. Defaults to the
+	// repository of GITHUB_REPOSITORY when Owner is unset, else all the
+	// repositories the installation can access.
+	Repositories []string
+	// A subset of the app's permissions, e.g. {"contents": "read"}. Defaults
+	// to all of them.
+	Permissions map[string]string
+	// Defaults to DefaultAppTokenExpiryDelta.
+	ExpiryDelta *time.Duration
+	// The client the app's requests are made with, authenticated with the
+	// app's JWT instead of its own token. Tokens are masked through its
+	// Output. Defaults to NewClient("", ClientOptions{}).
+	Client *Client
+}
+
+type appTokenSource struct {
+	appID        string
+	key          *rsa.PrivateKey
+	owner        string
+	repositories []string
+	permissions  map[string]string
+	expiryDelta  time.Duration
+	client       *Client
+
+	mu             sync.Mutex
+	installationID int64
+	token          *Token
+}
+
+// AppTokenSource returns a source of installation access tokens for the
+// GitHub App appID, signed for with privateKey, the PEM file GitHub
+// generates for the app. It finds the app's installation for the owner and
+// creates a token for it, which it masks and replaces before it expires.
+func AppTokenSource(appID string, privateKey []byte, options AppTokenOptions) (TokenSource, error) {
+	key, err := parseRSAPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	s := &appTokenSource{
+		appID:        appID,
+		key:          key,
+		repositories: options.Repositories,
+		permissions:  options.Permissions,
+		expiryDelta:  ptrOr(options.ExpiryDelta, DefaultAppTokenExpiryDelta),
+		client:       options.Client,
+	}
+	if options.Owner != nil {
+		s.owner = *options.Owner
+	} else {
+		owner, repo, ok := strings.Cut(os.Getenv("GITHUB_REPOSITORY"), "/")
+		if !ok {
+			return nil, errors.New("AppTokenSource requires an owner or a GITHUB_REPOSITORY environment variable like 'owner/repo'")
+		}
+		s.owner = owner
+		if s.repositories == nil {
+			s.repositories = []string{repo}
+		}
+	}
+	if s.client == nil {
+		s.client, err = NewClient("", ClientOptions{})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid private key: expected a PEM block")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid private key: expected an RSA key, got %T", key)
+	}
+	return rsaKey, nil
+}
+
+func (s *appTokenSource) Token() (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != nil && time.Now().Add(s.expiryDelta).Before(s.token.Expiry) {
+		return s.token.copy(), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), appTokenTimeout)
+	defer cancel()
+	jwt, err := s.jwt(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if s.installationID == 0 {
+		s.installationID, err = s.findInstallation(ctx, jwt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	body := map[string]any{}
+	if len(s.repositories) > 0 {
+		body["repositories"] = s.repositories
+	}
+	if len(s.permissions) > 0 {
+		body["permissions"] = s.permissions
+	}
+	var res struct {
+		Token               string            `json:"token"`
+		ExpiresAt           time.Time         `json:"expires_at"`
+		Permissions         map[string]string `json:"permissions"`
+		RepositorySelection string            `json:"repository_selection"`
+	}
+	err = s.do(ctx, jwt, "POST", fmt.Sprintf("/app/installations/%d/access_tokens", s.installationID), body, &res)
+	if err != nil {
+		return nil, fmt.Errorf("creating an installation token for app %s: %w", s.appID, err)
+	}
+	if res.Token == "" {
+		return nil, fmt.Errorf("creating an installation token for app %s: no token in the response", s.appID)
+	}
+	s.client.issueCommand("add-mask", res.Token)
+	s.token = &Token{
+		AccessToken:         res.Token,
+		Expiry:              res.ExpiresAt,
+		Permissions:         res.Permissions,
+		RepositorySelection: res.RepositorySelection,
+	}
+	s.client.debug(fmt.Sprintf("created an installation token for app %s expiring at %s", s.appID, res.ExpiresAt.Format(time.RFC3339)))
+	return s.token.copy(), nil
+}
+
+// copy returns a copy of t that callers can modify without affecting the
+// cached token.
+func (t *Token) copy() *Token {
+	c := *t
+	if t.Permissions != nil {
+		c.Permissions = make(map[string]string, len(t.Permissions))
+		for name, level := range t.Permissions {
+			c.Permissions[name] = level
+		}
+	}
+	return &c
+}
+
+// findInstallation looks up the installation through the first repository,
+// or the owner when the token is for all repositories. Owners are tried as
+// an organization, then as a user.
+func (s *appTokenSource) findInstallation(ctx context.Context, jwt string) (int64, error) {
+	var paths []string
+	if len(s.repositories) > 0 {
+		paths = []string{fmt.Sprintf("/repos/%s/%s/installation", url.PathEscape(s.owner), url.PathEscape(s.repositories[0]))}
+	} else {
+		paths = []string{
+			fmt.Sprintf("/orgs/%s/installation", url.PathEscape(s.owner)),
+			fmt.Sprintf("/users/%s/installation", url.PathEscape(s.owner)),
+		}
+	}
+	var res struct {
+		ID int64 `json:"id"`
+	}
+	var err error
+	for _, path := range paths {
+		err = s.do(ctx, jwt, "GET", path, nil, &res)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+			break
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("finding the installation of app %s for %s: %w", s.appID, s.owner, err)
+	}
+	return res.ID, nil
+}
+
+func (s *appTokenSource) do(ctx context.Context, jwt string, method string, path string, body any, out any) error {
+	req, err := s.client.newRequest(ctx, method, path, body, "Bearer "+jwt)
+	if err != nil {
+		return err
+	}
+	_, data, err := s.client.Send(req)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// jwt signs the RS256 JWT that authenticates as the app, see
+// https://docs.github.com/apps/creating-github-apps/authenticating-with-a-github-app/generating-a-json-web-token-jwt-for-a-github-app.
+// It is backdated a minute against clock drift and expires within the ten
+// minutes GitHub allows.
+func (s *appTokenSource) jwt(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": s.appID,
+	})
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
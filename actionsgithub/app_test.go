@@ -0,0 +1,262 @@
+package github_test
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	github "github.com/jcbhmr/go-toolkit/actionsgithub"
+)
+
+// newAppClient is newTestClient writing workflow commands to out.
+func newAppClient(t *testing.T, out io.Writer, handler http.Handler) *github.Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	c, err := github.NewClient("", github.ClientOptions{
+		BaseURL:  ptr(server.URL + "/api/v3/"),
+		Throttle: github.ThrottleOptions{WriteInterval: ptr(time.Duration(0))},
+		Output:   out,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func verifyJWT(t *testing.T, authorization string, key *rsa.PublicKey) map[string]any {
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	parts := strings.Split(token, ".")
+	if !ok || len(parts) != 3 {
+		t.Fatalf("expected a bearer JWT, got %q", authorization)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature)
+	if err != nil {
+		t.Fatalf("invalid JWT signature: %v", err)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var claims map[string]any
+	err = json.Unmarshal(payload, &claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return claims
+}
+
+func TestAppTokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	var out bytes.Buffer
+	lookups := 0
+	created := 0
+	c := newAppClient(t, &out, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v3/repos/octo/repo/installation":
+			lookups++
+			claims := verifyJWT(t, r.Header.Get("Authorization"), &key.PublicKey)
+			if claims["iss"] != "12345" {
+				t.Errorf("unexpected JWT claims %v", claims)
+			}
+			if exp, iat := claims["exp"].(float64), claims["iat"].(float64); exp-iat > 600 || int64(iat) > time.Now().Unix() {
+				t.Errorf("unexpected JWT lifetime %v", claims)
+			}
+			w.Write([]byte(`{"id":42,"account":{"login":"octo"}}`))
+		case "POST /api/v3/app/installations/42/access_tokens":
+			created++
+			verifyJWT(t, r.Header.Get("Authorization"), &key.PublicKey)
+			var body map[string]any
+			json.NewDecoder(r.Body).Decode(&body)
+			expected := map[string]any{"repositories": []any{"repo"}, "permissions": map[string]any{"issues": "write"}}
+			if !reflect.DeepEqual(body, expected) {
+				t.Errorf("expected %v, got %v", expected, body)
+			}
+			// The first token expires within the expiry delta.
+			expiresAt := time.Now().Add(time.Minute)
+			if created > 1 {
+				expiresAt = time.Now().Add(time.Hour)
+			}
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token":"ghs_installation%d","expires_at":%q,"permissions":{"issues":"write","metadata":"read"},"repository_selection":"selected"}`, created, expiresAt.Format(time.RFC3339))
+		case "GET /api/v3/repos/octo/repo/issues":
+			if r.Header.Get("Authorization") != "Bearer ghs_installation2" {
+				t.Errorf("unexpected Authorization %q", r.Header.Get("Authorization"))
+			}
+			w.Write([]byte(`[]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Setenv("GITHUB_REPOSITORY", "octo/repo")
+
+	source, err := github.AppTokenSource("12345", privateKey, github.AppTokenOptions{
+		Permissions: map[string]string{"issues": "write"},
+		Client:      c,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := source.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "ghs_installation1" || token.RepositorySelection != "selected" || token.Permissions["metadata"] != "read" {
+		t.Errorf("unexpected token %+v", token)
+	}
+	for range 2 {
+		token, err = source.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if token.AccessToken != "ghs_installation2" || lookups != 1 || created != 2 {
+		t.Errorf("expected one refresh, got %q after %d lookups and %d tokens", token.AccessToken, lookups, created)
+	}
+
+	installationClient, err := github.NewClient("", github.ClientOptions{BaseURL: ptr(c.BaseURL()), TokenSource: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = github.Do[[]any](context.Background(), installationClient, "GET", "/repos/octo/repo/issues", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"::add-mask::ghs_installation1\n", "::add-mask::ghs_installation2\n"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q to contain %q", out.String(), expected)
+		}
+	}
+
+	// Tokens are copies, so callers can't change the cached one.
+	token.AccessToken = "changed"
+	token.Permissions["issues"] = "admin"
+	token, err = source.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "ghs_installation2" || token.Permissions["issues"] != "write" || created != 2 {
+		t.Errorf("expected the cached token unchanged, got %+v", token)
+	}
+}
+
+func TestAppTokenSourceOwner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	// Organizations are looked up first, then users.
+	lookups := map[string][]string{
+		"octo-org": {"GET /api/v3/orgs/octo-org/installation", "POST /api/v3/app/installations/7/access_tokens"},
+		"octocat":  {"GET /api/v3/orgs/octocat/installation", "GET /api/v3/users/octocat/installation", "POST /api/v3/app/installations/8/access_tokens"},
+	}
+	for owner, expected := range lookups {
+		var out bytes.Buffer
+		var requests []string
+		c := newAppClient(t, &out, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			switch r.Method + " " + r.URL.Path {
+			case "GET /api/v3/orgs/octo-org/installation":
+				w.Write([]byte(`{"id":7}`))
+			case "GET /api/v3/users/octocat/installation":
+				w.Write([]byte(`{"id":8}`))
+			case "POST /api/v3/app/installations/7/access_tokens", "POST /api/v3/app/installations/8/access_tokens":
+				var body map[string]any
+				json.NewDecoder(r.Body).Decode(&body)
+				if len(body) != 0 {
+					t.Errorf("expected a token for all repositories, got %v", body)
+				}
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprintf(w, `{"token":"ghs_%s","expires_at":%q,"repository_selection":"all"}`, owner, time.Now().Add(time.Hour).Format(time.RFC3339))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"message":"Not Found"}`))
+			}
+		}))
+		source, err := github.AppTokenSource("12345", privateKey, github.AppTokenOptions{Owner: ptr(owner), Client: c})
+		if err != nil {
+			t.Fatal(err)
+		}
+		token, err := source.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.AccessToken != "ghs_"+owner || token.RepositorySelection != "all" {
+			t.Errorf("%s: unexpected token %+v", owner, token)
+		}
+		if !strings.Contains(out.String(), "::add-mask::ghs_"+owner+"\n") {
+			t.Errorf("%s: expected the token to be masked, got %q", owner, out.String())
+		}
+		if !reflect.DeepEqual(requests, expected) {
+			t.Errorf("%s: expected %v, got %v", owner, expected, requests)
+		}
+	}
+}
+
+func TestAppTokenSourceErrors(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	_, err := github.AppTokenSource("1", []byte("not a key"), github.AppTokenOptions{Owner: ptr("octo")})
+	if err == nil {
+		t.Error("expected an error for an invalid key")
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})
+	_, err = github.AppTokenSource("1", privateKey, github.AppTokenOptions{})
+	if err == nil {
+		t.Error("expected an error without an owner")
+	}
+
+	var requests []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"Not Found","documentation_url":"https://docs.github.com/rest/apps/apps#get-a-user-installation-for-the-authenticated-app"}`))
+	}))
+	source, err := github.AppTokenSource("1", privateKey, github.AppTokenOptions{Owner: ptr("octo"), Client: c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = source.Token()
+	if err == nil || !strings.Contains(err.Error(), "finding the installation of app 1 for octo") {
+		t.Errorf("unexpected error %v", err)
+	}
+	expected := []string{"/api/v3/orgs/octo/installation", "/api/v3/users/octo/installation"}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("expected %v, got %v", expected, requests)
+	}
+}
@@ -0,0 +1,290 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	DefaultUserAgent  = "go-toolkit-actionsgithub"
+	DefaultAPIVersion = "2022-11-28"
+)
+
+type ClientOptions struct {
+	// Defaults to GITHUB_API_URL, then https://api.github.com. For GitHub
+	// Enterprise Server it is https://<host>/api/v3.
+	BaseURL *string
+	// Defaults to the endpoint matching BaseURL when that is set, e.g.
+	// https://<host>/api/graphql for GitHub Enterprise Server, else
+	// GITHUB_GRAPHQL_URL, else https://api.github.com/graphql.
+	GraphQLURL *string
+	// Defaults to DefaultUserAgent.
+	UserAgent *string
+	// Sent as X-GitHub-Api-Version. Defaults to DefaultAPIVersion.
+	APIVersion *string
+	// Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// Supplies the token for each request instead of the token NewClient is
+	// given, e.g. an AppTokenSource.
+	TokenSource TokenSource
+	Throttle    ThrottleOptions
+	// Receives debug logs, such as when the client waits for a rate limit.
+	// Defaults to writing ::debug:: workflow commands to Output.
+	Debug func(message string)
+	// Where workflow commands, like the add-mask of an AppTokenSource's
+	// tokens, are written. Defaults to os.Stdout.
+	Output io.Writer
+}
+
+// Client is a client for the GitHub REST API, the equivalent of the Octokit
+// instance @actions/github's getOctokit returns.
+type Client struct {
+	token       string
+	tokenSource TokenSource
+	baseURL     string
+	apiHost     string
+	graphQLURL  string
+	graphQLHost string
+	userAgent   string
+	apiVersion  string
+	httpClient  *http.Client
+	throttle    *throttle
+	debug       func(message string)
+	output      io.Writer
+}
+
+// NewClient returns a client authenticated with token, usually GITHUB_TOKEN.
+// An empty token makes unauthenticated requests.
+func NewClient(token string, options ClientOptions) (*Client, error) {
+	baseURL := "https://api.github.com"
+	if env := os.Getenv("GITHUB_API_URL"); env != "" {
+		baseURL = env
+	}
+	if options.BaseURL != nil {
+		baseURL = *options.BaseURL
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: expected http or https", baseURL)
+	}
+	c := &Client{
+		token:       token,
+		tokenSource: options.TokenSource,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		apiHost:     u.Host,
+		userAgent:   DefaultUserAgent,
+		apiVersion:  DefaultAPIVersion,
+		httpClient:  http.DefaultClient,
+		throttle:    newThrottle(options.Throttle),
+		output:      os.Stdout,
+	}
+	c.debug = func(message string) {
+		c.issueCommand("debug", message)
+	}
+	c.graphQLURL = graphQLURLFor(c.baseURL)
+	if env := os.Getenv("GITHUB_GRAPHQL_URL"); env != "" && options.BaseURL == nil {
+		c.graphQLURL = env
+	}
+	if options.GraphQLURL != nil {
+		c.graphQLURL = *options.GraphQLURL
+	}
+	u, err = url.Parse(c.graphQLURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GraphQL URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid GraphQL URL %q: expected http or https", c.graphQLURL)
+	}
+	c.graphQLHost = u.Host
+	if options.UserAgent != nil {
+		c.userAgent = *options.UserAgent
+	}
+	if options.APIVersion != nil {
+		c.apiVersion = *options.APIVersion
+	}
+	if options.HTTPClient != nil {
+		c.httpClient = options.HTTPClient
+	}
+	if options.Debug != nil {
+		c.debug = options.Debug
+	}
+	if options.Output != nil {
+		c.output = options.Output
+	}
+	return c, nil
+}
+
+// BaseURL returns the API URL paths are resolved against.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// URL resolves path, like /repos/{owner}/{repo}, against the base URL.
+// Absolute URLs are returned as they are.
+func (c *Client) URL(path string) string {
+	if strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://") {
+		return path
+	}
+	return c.baseURL + "/" + strings.TrimPrefix(path, "/")
+}
+
+// NewRequest builds a request for path with body encoded as JSON. A nil body
+// sends no body. The token is only sent to the REST and GraphQL API hosts, so
+// absolute URLs elsewhere, such as a Link header pointing off-site, don't
+// receive it.
+func (c *Client) NewRequest(ctx context.Context, method string, path string, body any) (*http.Request, error) {
+	authorization := ""
+	if c.tokenSource != nil {
+		token, err := c.tokenSource.Token()
+		if err != nil {
+			return nil, err
+		}
+		authorization = "Bearer " + token.AccessToken
+	} else if c.token != "" {
+		authorization = "Bearer " + c.token
+	}
+	return c.newRequest(ctx, method, path, body, authorization)
+}
+
+func (c *Client) newRequest(ctx context.Context, method string, path string, body any, authorization string) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.URL(path), reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.apiVersion != "" {
+		req.Header.Set("X-GitHub-Api-Version", c.apiVersion)
+	}
+	if authorization != "" && (strings.EqualFold(req.URL.Host, c.apiHost) || strings.EqualFold(req.URL.Host, c.graphQLHost)) {
+		req.Header.Set("Authorization", authorization)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+func (c *Client) send(req *http.Request) (*http.Response, []byte, error) {
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res, body, newAPIError(req, res, body)
+	}
+	return res, body, nil
+}
+
+// Do calls an endpoint and decodes its JSON response into a T. Use any, or
+// struct{} to ignore the response.
+//
+//	repo, err := github.Do[github.Repository](ctx, client, "GET", "/repos/octo/repo", nil)
+func Do[T any](ctx context.Context, c *Client, method string, path string, body any) (T, error) {
+	var v T
+	req, err := c.NewRequest(ctx, method, path, body)
+	if err != nil {
+		return v, err
+	}
+	_, data, err := c.Send(req)
+	if err != nil {
+		return v, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return v, nil
+	}
+	err = json.Unmarshal(data, &v)
+	if err != nil {
+		return v, fmt.Errorf("%s %s: decoding response: %w", method, req.URL.Path, err)
+	}
+	return v, nil
+}
+
+// APIError is an error response of the REST API.
+type APIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Header     http.Header
+
+	Message          string           `json:"message"`
+	DocumentationURL string           `json:"documentation_url"`
+	Errors           []APIErrorDetail `json:"errors"`
+}
+
+// APIErrorDetail explains a validation error, see
+// https://docs.github.com/rest/using-the-rest-api/troubleshooting-the-rest-api.
+type APIErrorDetail struct {
+	Resource string `json:"resource"`
+	Field    string `json:"field"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+}
+
+// UnmarshalJSON accepts errors given as plain strings, which some endpoints
+// return.
+func (d *APIErrorDetail) UnmarshalJSON(data []byte) error {
+	var message string
+	if json.Unmarshal(data, &message) == nil {
+		*d = APIErrorDetail{Message: message}
+		return nil
+	}
+	type plain APIErrorDetail
+	return json.Unmarshal(data, (*plain)(d))
+}
+
+func newAPIError(req *http.Request, res *http.Response, body []byte) *APIError {
+	e := &APIError{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+	}
+	if json.Unmarshal(body, e) != nil || e.Message == "" {
+		e.Message = strings.TrimSpace(string(body))
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(res.StatusCode)
+	}
+	return e
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s: %d %s", e.Method, e.URL, e.StatusCode, e.Message)
+	for _, detail := range e.Errors {
+		switch {
+		case detail.Message != "":
+			fmt.Fprintf(&b, "; %s", detail.Message)
+		case detail.Field != "":
+			fmt.Fprintf(&b, "; %s.%s %s", detail.Resource, detail.Field, detail.Code)
+		}
+	}
+	if e.DocumentationURL != "" {
+		fmt.Fprintf(&b, " (see %s)", e.DocumentationURL)
+	}
+	return b.String()
+}
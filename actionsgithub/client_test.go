@@ -0,0 +1,147 @@
+package github_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	github "github.com/jcbhmr/go-toolkit/actionsgithub"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func newTestClient(t *testing.T, handler http.Handler) *github.Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	c, err := github.NewClient("ghs_token", github.ClientOptions{
+		BaseURL:  ptr(server.URL + "/api/v3/"),
+		Throttle: github.ThrottleOptions{WriteInterval: ptr(time.Duration(0))},
+		Debug:    func(message string) { t.Log(message) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestDo(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ghs_token" {
+			t.Errorf("unexpected Authorization %q", r.Header.Get("Authorization"))
+		}
+		if r.Header.Get("User-Agent") != github.DefaultUserAgent || r.Header.Get("X-GitHub-Api-Version") != github.DefaultAPIVersion {
+			t.Errorf("unexpected headers %v", r.Header)
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v3/repos/octo/repo":
+			w.Write([]byte(`{"name":"repo","full_name":"octo/repo","owner":{"login":"octo"}}`))
+		case "POST /api/v3/repos/octo/repo/issues/1/comments":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if r.Header.Get("Content-Type") != "application/json" || body["body"] != "hi" {
+				t.Errorf("unexpected body %v", body)
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":1,"body":"hi"}`))
+		case "DELETE /api/v3/repos/octo/repo/issues/comments/1":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	ctx := context.Background()
+
+	repo, err := github.Do[github.Repository](ctx, c, "GET", "/repos/octo/repo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.FullName != "octo/repo" || repo.Owner.Login != "octo" {
+		t.Errorf("unexpected repository %+v", repo)
+	}
+
+	comment, err := github.Do[github.IssueComment](ctx, c, "POST", "repos/octo/repo/issues/1/comments", map[string]string{"body": "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment.ID != 1 || comment.Body != "hi" {
+		t.Errorf("unexpected comment %+v", comment)
+	}
+
+	_, err = github.Do[struct{}](ctx, c, "DELETE", "/repos/octo/repo/issues/comments/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"message":"Validation Failed","errors":[{"resource":"Issue","field":"title","code":"missing_field"},"bad label"],"documentation_url":"https://docs.github.com/rest/issues/issues#create-an-issue"}`))
+	}))
+
+	_, err := github.Do[any](context.Background(), c, "POST", "/repos/octo/repo/issues", map[string]string{})
+	var apiErr *github.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %T %v", err, err)
+	}
+	if apiErr.StatusCode != 422 || apiErr.Message != "Validation Failed" || apiErr.DocumentationURL != "https://docs.github.com/rest/issues/issues#create-an-issue" {
+		t.Errorf("unexpected error %+v", apiErr)
+	}
+	if len(apiErr.Errors) != 2 || apiErr.Errors[0].Field != "title" || apiErr.Errors[1].Message != "bad label" {
+		t.Errorf("unexpected error details %+v", apiErr.Errors)
+	}
+	for _, s := range []string{"422 Validation Failed", "Issue.title missing_field", "bad label", "(see https://docs.github.com"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("expected %q to contain %q", err.Error(), s)
+		}
+	}
+}
+
+func TestNewRequestCredentials(t *testing.T) {
+	c, err := github.NewClient("ghs_token", github.ClientOptions{BaseURL: ptr("https://ghes.example.com/api/v3")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for url, expected := range map[string]string{
+		"/repos/octo/repo": "Bearer ghs_token",
+		"https://GHES.example.com/api/v3/repos?page=2": "Bearer ghs_token",
+		"https://uploads.example.com/x":                "",
+		"https://evil.example.com/api/v3/repos?page=2": "",
+		"https://ghes.example.com.evil.example.com/x":  "",
+	} {
+		req, err := c.NewRequest(context.Background(), "GET", url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := req.Header.Get("Authorization"); got != expected {
+			t.Errorf("%s: expected Authorization %q, got %q", url, expected, got)
+		}
+	}
+}
+
+func TestNewClientBaseURL(t *testing.T) {
+	t.Setenv("GITHUB_API_URL", "https://ghes.example.com/api/v3")
+	c, err := github.NewClient("", github.ClientOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.URL("/repos/octo/repo"); got != "https://ghes.example.com/api/v3/repos/octo/repo" {
+		t.Errorf("unexpected URL %q", got)
+	}
+	if got := c.URL("https://uploads.example.com/x"); got != "https://uploads.example.com/x" {
+		t.Errorf("unexpected URL %q", got)
+	}
+
+	_, err = github.NewClient("", github.ClientOptions{BaseURL: ptr("ftp://example.com")})
+	if err == nil {
+		t.Error("expected an error for a non-HTTP base URL")
+	}
+}
@@ -0,0 +1,15 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The package doesn't depend on actionscore, so it writes the few workflow
+// commands it needs itself.
+
+var commandEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+func (c *Client) issueCommand(command string, message string) {
+	fmt.Fprintf(c.output, "::%s::%s\n", command, commandEscaper.Replace(message))
+}
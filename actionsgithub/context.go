@@ -0,0 +1,193 @@
+// Package github gives actions the context of the workflow run they are part
+// of, the way @actions/github does for JavaScript actions.
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Context is the github context of a workflow run, read from the GITHUB_*
+// variables the runner sets. See
+// https://docs.github.com/actions/writing-workflows/choosing-what-your-workflow-does/store-information-in-variables#default-environment-variables.
+type Context struct {
+	// The webhook payload of the event that triggered the run, read from
+	// GITHUB_EVENT_PATH. Use Event or Payload for a typed payload.
+	Payload   map[string]any
+	EventName string
+	EventPath string
+
+	SHA string
+	Ref string
+	// The short ref name, e.g. "main" or "feature/x" or "123/merge".
+	RefName string
+	// "branch" or "tag".
+	RefType      string
+	RefProtected bool
+	// Only set for pull_request and pull_request_target events.
+	BaseRef string
+	HeadRef string
+
+	Workflow    string
+	WorkflowRef string
+	WorkflowSHA string
+	Action      string
+	Job         string
+	RunID       int64
+	RunNumber   int64
+	RunAttempt  int64
+
+	Actor             string
+	ActorID           int64
+	TriggeringActor   string
+	Repository        string
+	RepositoryID      int64
+	RepositoryOwner   string
+	RepositoryOwnerID int64
+
+	ServerURL  string
+	APIURL     string
+	GraphQLURL string
+	Workspace  string
+
+	rawPayload []byte
+}
+
+// NewContext reads the context from the environment. A missing event payload
+// file leaves Payload empty.
+func NewContext() (*Context, error) {
+	c := &Context{
+		Payload:         map[string]any{},
+		EventName:       os.Getenv("GITHUB_EVENT_NAME"),
+		EventPath:       os.Getenv("GITHUB_EVENT_PATH"),
+		SHA:             os.Getenv("GITHUB_SHA"),
+		Ref:             os.Getenv("GITHUB_REF"),
+		RefName:         os.Getenv("GITHUB_REF_NAME"),
+		RefType:         os.Getenv("GITHUB_REF_TYPE"),
+		RefProtected:    os.Getenv("GITHUB_REF_PROTECTED") == "true",
+		BaseRef:         os.Getenv("GITHUB_BASE_REF"),
+		HeadRef:         os.Getenv("GITHUB_HEAD_REF"),
+		Workflow:        os.Getenv("GITHUB_WORKFLOW"),
+		WorkflowRef:     os.Getenv("GITHUB_WORKFLOW_REF"),
+		WorkflowSHA:     os.Getenv("GITHUB_WORKFLOW_SHA"),
+		Action:          os.Getenv("GITHUB_ACTION"),
+		Job:             os.Getenv("GITHUB_JOB"),
+		Actor:           os.Getenv("GITHUB_ACTOR"),
+		TriggeringActor: os.Getenv("GITHUB_TRIGGERING_ACTOR"),
+		Repository:      os.Getenv("GITHUB_REPOSITORY"),
+		RepositoryOwner: os.Getenv("GITHUB_REPOSITORY_OWNER"),
+		ServerURL:       getenvDefault("GITHUB_SERVER_URL", "https://github.com"),
+		APIURL:          getenvDefault("GITHUB_API_URL", "https://api.github.com"),
+		GraphQLURL:      getenvDefault("GITHUB_GRAPHQL_URL", "https://api.github.com/graphql"),
+		Workspace:       os.Getenv("GITHUB_WORKSPACE"),
+	}
+
+	var err error
+	for _, v := range []struct {
+		name  string
+		field *int64
+	}{
+		{"GITHUB_RUN_ID", &c.RunID},
+		{"GITHUB_RUN_NUMBER", &c.RunNumber},
+		{"GITHUB_RUN_ATTEMPT", &c.RunAttempt},
+		{"GITHUB_ACTOR_ID", &c.ActorID},
+		{"GITHUB_REPOSITORY_ID", &c.RepositoryID},
+		{"GITHUB_REPOSITORY_OWNER_ID", &c.RepositoryOwnerID},
+	} {
+		value := os.Getenv(v.name)
+		if value == "" {
+			continue
+		}
+		*v.field, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", v.name, err)
+		}
+	}
+
+	if c.EventPath != "" {
+		content, err := os.ReadFile(c.EventPath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		if err == nil {
+			err = json.Unmarshal(content, &c.Payload)
+			if err != nil {
+				return nil, fmt.Errorf("invalid event payload %s: %w", c.EventPath, err)
+			}
+			c.rawPayload = content
+		}
+	}
+	return c, nil
+}
+
+func getenvDefault(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+type Repo struct {
+	Owner string
+	Repo  string
+}
+
+// Repo returns the repository the run belongs to, from GITHUB_REPOSITORY or
+// else the payload's repository.
+func (c *Context) Repo() (Repo, error) {
+	if owner, repo, ok := strings.Cut(c.Repository, "/"); ok {
+		return Repo{Owner: owner, Repo: repo}, nil
+	}
+	if repository, ok := c.Payload["repository"].(map[string]any); ok {
+		owner, _ := repository["owner"].(map[string]any)
+		login, _ := owner["login"].(string)
+		name, _ := repository["name"].(string)
+		if login != "" && name != "" {
+			return Repo{Owner: login, Repo: name}, nil
+		}
+	}
+	return Repo{}, errors.New("context.Repo requires a GITHUB_REPOSITORY environment variable like 'owner/repo'")
+}
+
+type Issue struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+// Issue returns the issue or pull request the event is about: the number of
+// the payload's issue, pull_request or the payload itself.
+func (c *Context) Issue() (Issue, error) {
+	repo, err := c.Repo()
+	if err != nil {
+		return Issue{}, err
+	}
+	number, ok := payloadNumber(c.Payload["issue"])
+	if !ok {
+		number, ok = payloadNumber(c.Payload["pull_request"])
+	}
+	if !ok {
+		number, ok = payloadNumber(c.Payload)
+	}
+	if !ok {
+		return Issue{}, fmt.Errorf("the %s event payload has no issue or pull request number", c.EventName)
+	}
+	return Issue{Owner: repo.Owner, Repo: repo.Repo, Number: number}, nil
+}
+
+func payloadNumber(v any) (int, bool) {
+	object, ok := v.(map[string]any)
+	if !ok {
+		return 0, false
+	}
+	number, ok := object["number"].(float64)
+	if !ok {
+		return 0, false
+	}
+	return int(number), true
+}
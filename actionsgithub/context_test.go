@@ -0,0 +1,132 @@
+package github_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	github "github.com/jcbhmr/go-toolkit/actionsgithub"
+)
+
+func setEvent(t *testing.T, name string, payload string) {
+	path := filepath.Join(t.TempDir(), "event.json")
+	err := os.WriteFile(path, []byte(payload), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_EVENT_NAME", name)
+	t.Setenv("GITHUB_EVENT_PATH", path)
+}
+
+func TestNewContext(t *testing.T) {
+	env := map[string]string{
+		"GITHUB_SHA":                 "ffac537e6cbbf934b08745a378932722df287a53",
+		"GITHUB_REF":                 "refs/pull/7/merge",
+		"GITHUB_REF_NAME":            "7/merge",
+		"GITHUB_REF_TYPE":            "branch",
+		"GITHUB_REF_PROTECTED":       "false",
+		"GITHUB_BASE_REF":            "main",
+		"GITHUB_HEAD_REF":            "feature",
+		"GITHUB_WORKFLOW":            "CI",
+		"GITHUB_WORKFLOW_REF":        "octo/repo/.github/workflows/ci.yml@refs/pull/7/merge",
+		"GITHUB_JOB":                 "test",
+		"GITHUB_RUN_ID":              "1658821493",
+		"GITHUB_RUN_NUMBER":          "12",
+		"GITHUB_RUN_ATTEMPT":         "2",
+		"GITHUB_ACTOR":               "octocat",
+		"GITHUB_ACTOR_ID":            "583231",
+		"GITHUB_TRIGGERING_ACTOR":    "hubot",
+		"GITHUB_REPOSITORY":          "octo/repo",
+		"GITHUB_REPOSITORY_ID":       "123456",
+		"GITHUB_REPOSITORY_OWNER":    "octo",
+		"GITHUB_REPOSITORY_OWNER_ID": "654321",
+		"GITHUB_SERVER_URL":          "",
+		"GITHUB_API_URL":             "",
+		"GITHUB_GRAPHQL_URL":         "",
+	}
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+	setEvent(t, "pull_request", `{"action":"opened","number":7,"pull_request":{"number":7}}`)
+
+	c, err := github.NewContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.EventName != "pull_request" || c.Payload["action"] != "opened" {
+		t.Errorf("unexpected event %q %v", c.EventName, c.Payload)
+	}
+	if c.RefName != "7/merge" || c.RefType != "branch" || c.RefProtected || c.BaseRef != "main" || c.HeadRef != "feature" {
+		t.Errorf("unexpected ref fields %+v", c)
+	}
+	if c.RunID != 1658821493 || c.RunNumber != 12 || c.RunAttempt != 2 {
+		t.Errorf("unexpected run fields %d %d %d", c.RunID, c.RunNumber, c.RunAttempt)
+	}
+	if c.ActorID != 583231 || c.TriggeringActor != "hubot" || c.RepositoryID != 123456 || c.RepositoryOwnerID != 654321 {
+		t.Errorf("unexpected ids %+v", c)
+	}
+	if c.ServerURL != "https://github.com" || c.APIURL != "https://api.github.com" || c.GraphQLURL != "https://api.github.com/graphql" {
+		t.Errorf("expected default URLs, got %q %q %q", c.ServerURL, c.APIURL, c.GraphQLURL)
+	}
+
+	repo, err := c.Repo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo != (github.Repo{Owner: "octo", Repo: "repo"}) {
+		t.Errorf("unexpected repo %+v", repo)
+	}
+	issue, err := c.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issue != (github.Issue{Owner: "octo", Repo: "repo", Number: 7}) {
+		t.Errorf("unexpected issue %+v", issue)
+	}
+}
+
+func TestContextRepoFromPayload(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	setEvent(t, "issues", `{"issue":{"number":3},"repository":{"name":"repo","owner":{"login":"octo"}}}`)
+
+	c, err := github.NewContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	issue, err := c.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issue != (github.Issue{Owner: "octo", Repo: "repo", Number: 3}) {
+		t.Errorf("unexpected issue %+v", issue)
+	}
+}
+
+func TestContextErrors(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("GITHUB_EVENT_PATH", filepath.Join(t.TempDir(), "missing.json"))
+	c, err := github.NewContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Payload) != 0 {
+		t.Errorf("expected an empty payload, got %v", c.Payload)
+	}
+	_, err = c.Repo()
+	if err == nil {
+		t.Error("expected an error without a repository")
+	}
+
+	t.Setenv("GITHUB_RUN_ID", "abc")
+	_, err = github.NewContext()
+	if err == nil {
+		t.Error("expected an error for an invalid GITHUB_RUN_ID")
+	}
+
+	t.Setenv("GITHUB_RUN_ID", "1")
+	setEvent(t, "push", "{")
+	_, err = github.NewContext()
+	if err == nil {
+		t.Error("expected an error for an invalid payload")
+	}
+}
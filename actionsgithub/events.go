@@ -0,0 +1,312 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// The structs below cover the fields actions commonly use; the full payloads
+// are documented at https://docs.github.com/webhooks/webhook-events-and-payloads.
+// Anything else is still in Context.Payload.
+
+type User struct {
+	Login   string `json:"login"`
+	ID      int64  `json:"id"`
+	NodeID  string `json:"node_id"`
+	Type    string `json:"type"`
+	HTMLURL string `json:"html_url"`
+}
+
+type Repository struct {
+	ID            int64  `json:"id"`
+	NodeID        string `json:"node_id"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Owner         User   `json:"owner"`
+	Private       bool   `json:"private"`
+	Visibility    string `json:"visibility"`
+	Fork          bool   `json:"fork"`
+	Archived      bool   `json:"archived"`
+	DefaultBranch string `json:"default_branch"`
+	HTMLURL       string `json:"html_url"`
+	CloneURL      string `json:"clone_url"`
+}
+
+type Label struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type CommitAuthor struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+type PushCommit struct {
+	ID        string       `json:"id"`
+	TreeID    string       `json:"tree_id"`
+	Message   string       `json:"message"`
+	Timestamp time.Time    `json:"timestamp"`
+	URL       string       `json:"url"`
+	Author    CommitAuthor `json:"author"`
+	Committer CommitAuthor `json:"committer"`
+	Added     []string     `json:"added"`
+	Removed   []string     `json:"removed"`
+	Modified  []string     `json:"modified"`
+}
+
+type PushEvent struct {
+	Ref        string       `json:"ref"`
+	Before     string       `json:"before"`
+	After      string       `json:"after"`
+	BaseRef    *string      `json:"base_ref"`
+	Created    bool         `json:"created"`
+	Deleted    bool         `json:"deleted"`
+	Forced     bool         `json:"forced"`
+	Compare    string       `json:"compare"`
+	Commits    []PushCommit `json:"commits"`
+	HeadCommit *PushCommit  `json:"head_commit"`
+	Pusher     CommitAuthor `json:"pusher"`
+	Repository Repository   `json:"repository"`
+	Sender     User         `json:"sender"`
+}
+
+type PullRequestBranch struct {
+	Label string      `json:"label"`
+	Ref   string      `json:"ref"`
+	SHA   string      `json:"sha"`
+	User  User        `json:"user"`
+	Repo  *Repository `json:"repo"`
+}
+
+type PullRequest struct {
+	ID             int64             `json:"id"`
+	NodeID         string            `json:"node_id"`
+	Number         int               `json:"number"`
+	State          string            `json:"state"`
+	Title          string            `json:"title"`
+	Body           *string           `json:"body"`
+	Draft          bool              `json:"draft"`
+	Merged         bool              `json:"merged"`
+	MergeCommitSHA *string           `json:"merge_commit_sha"`
+	User           User              `json:"user"`
+	Labels         []Label           `json:"labels"`
+	Head           PullRequestBranch `json:"head"`
+	Base           PullRequestBranch `json:"base"`
+	HTMLURL        string            `json:"html_url"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// PullRequestEvent is the payload of pull_request and pull_request_target.
+type PullRequestEvent struct {
+	Action      string      `json:"action"`
+	Number      int         `json:"number"`
+	PullRequest PullRequest `json:"pull_request"`
+	// Set for the labeled and unlabeled actions.
+	Label      *Label     `json:"label"`
+	Before     string     `json:"before"`
+	After      string     `json:"after"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+}
+
+// EventIssue is the issue object of issues and issue_comment payloads. Issue
+// is just enough to address one.
+type EventIssue struct {
+	ID        int64     `json:"id"`
+	NodeID    string    `json:"node_id"`
+	Number    int       `json:"number"`
+	State     string    `json:"state"`
+	Title     string    `json:"title"`
+	Body      *string   `json:"body"`
+	User      User      `json:"user"`
+	Labels    []Label   `json:"labels"`
+	Assignees []User    `json:"assignees"`
+	HTMLURL   string    `json:"html_url"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Set when the issue is a pull request.
+	PullRequest *struct {
+		URL     string `json:"url"`
+		HTMLURL string `json:"html_url"`
+	} `json:"pull_request"`
+}
+
+type IssuesEvent struct {
+	Action     string     `json:"action"`
+	Issue      EventIssue `json:"issue"`
+	Label      *Label     `json:"label"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+}
+
+type IssueComment struct {
+	ID                int64     `json:"id"`
+	NodeID            string    `json:"node_id"`
+	Body              string    `json:"body"`
+	User              User      `json:"user"`
+	AuthorAssociation string    `json:"author_association"`
+	HTMLURL           string    `json:"html_url"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type IssueCommentEvent struct {
+	Action     string       `json:"action"`
+	Issue      EventIssue   `json:"issue"`
+	Comment    IssueComment `json:"comment"`
+	Repository Repository   `json:"repository"`
+	Sender     User         `json:"sender"`
+}
+
+type ReleaseAsset struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	ContentType        string `json:"content_type"`
+	Size               int64  `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+type Release struct {
+	ID              int64          `json:"id"`
+	NodeID          string         `json:"node_id"`
+	TagName         string         `json:"tag_name"`
+	TargetCommitish string         `json:"target_commitish"`
+	Name            *string        `json:"name"`
+	Body            *string        `json:"body"`
+	Draft           bool           `json:"draft"`
+	Prerelease      bool           `json:"prerelease"`
+	Author          User           `json:"author"`
+	Assets          []ReleaseAsset `json:"assets"`
+	HTMLURL         string         `json:"html_url"`
+	UploadURL       string         `json:"upload_url"`
+	CreatedAt       time.Time      `json:"created_at"`
+	PublishedAt     *time.Time     `json:"published_at"`
+}
+
+type ReleaseEvent struct {
+	Action     string     `json:"action"`
+	Release    Release    `json:"release"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+}
+
+type WorkflowDispatchEvent struct {
+	// Inputs are strings, or booleans and numbers for inputs declared with
+	// those types.
+	Inputs     map[string]any `json:"inputs"`
+	Ref        string         `json:"ref"`
+	Workflow   string         `json:"workflow"`
+	Repository Repository     `json:"repository"`
+	Sender     User           `json:"sender"`
+}
+
+type WorkflowRun struct {
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	Event        string     `json:"event"`
+	Status       string     `json:"status"`
+	Conclusion   *string    `json:"conclusion"`
+	HeadBranch   string     `json:"head_branch"`
+	HeadSHA      string     `json:"head_sha"`
+	RunNumber    int64      `json:"run_number"`
+	RunAttempt   int64      `json:"run_attempt"`
+	WorkflowID   int64      `json:"workflow_id"`
+	Path         string     `json:"path"`
+	HTMLURL      string     `json:"html_url"`
+	Actor        User       `json:"actor"`
+	HeadRepo     Repository `json:"head_repository"`
+	PullRequests []struct {
+		Number int `json:"number"`
+		Head   struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"base"`
+	} `json:"pull_requests"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WorkflowRunEvent struct {
+	Action      string      `json:"action"`
+	WorkflowRun WorkflowRun `json:"workflow_run"`
+	Workflow    struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+		Path string `json:"path"`
+	} `json:"workflow"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+}
+
+type MergeGroupEvent struct {
+	Action     string `json:"action"`
+	MergeGroup struct {
+		HeadSHA    string     `json:"head_sha"`
+		HeadRef    string     `json:"head_ref"`
+		BaseSHA    string     `json:"base_sha"`
+		BaseRef    string     `json:"base_ref"`
+		HeadCommit PushCommit `json:"head_commit"`
+	} `json:"merge_group"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+}
+
+type ScheduleEvent struct {
+	// The cron expression that triggered the run.
+	Schedule string `json:"schedule"`
+}
+
+// Payload decodes the event payload into a T, such as PushEvent.
+func Payload[T any](c *Context) (*T, error) {
+	raw := c.rawPayload
+	if raw == nil {
+		var err error
+		raw, err = json.Marshal(c.Payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+	v := new(T)
+	err := json.Unmarshal(raw, v)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s event payload: %w", c.EventName, err)
+	}
+	return v, nil
+}
+
+// Event decodes the event payload into the struct for the event, such as
+// *PushEvent for push. Events without a struct are returned as the raw
+// map[string]any of Context.Payload.
+func (c *Context) Event() (any, error) {
+	switch c.EventName {
+	case "push":
+		return Payload[PushEvent](c)
+	case "pull_request", "pull_request_target":
+		return Payload[PullRequestEvent](c)
+	case "issues":
+		return Payload[IssuesEvent](c)
+	case "issue_comment":
+		return Payload[IssueCommentEvent](c)
+	case "release":
+		return Payload[ReleaseEvent](c)
+	case "workflow_dispatch":
+		return Payload[WorkflowDispatchEvent](c)
+	case "workflow_run":
+		return Payload[WorkflowRunEvent](c)
+	case "merge_group":
+		return Payload[MergeGroupEvent](c)
+	case "schedule":
+		return Payload[ScheduleEvent](c)
+	default:
+		return c.Payload, nil
+	}
+}
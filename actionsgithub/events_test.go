@@ -0,0 +1,133 @@
+package github_test
+
+import (
+	"testing"
+
+	github "github.com/jcbhmr/go-toolkit/actionsgithub"
+)
+
+func TestPayload(t *testing.T) {
+	setEvent(t, "push", `{
+		"ref": "refs/heads/main",
+		"before": "0000000000000000000000000000000000000000",
+		"after": "ffac537e6cbbf934b08745a378932722df287a53",
+		"created": true,
+		"commits": [{"id": "ffac537", "message": "Initial commit", "timestamp": "2024-10-01T12:00:00Z", "author": {"name": "Mona", "username": "octocat"}, "added": ["README.md"]}],
+		"head_commit": {"id": "ffac537", "message": "Initial commit"},
+		"repository": {"name": "repo", "full_name": "octo/repo", "owner": {"login": "octo"}, "default_branch": "main"},
+		"sender": {"login": "octocat", "id": 1}
+	}`)
+	c, err := github.NewContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	push, err := github.Payload[github.PushEvent](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if push.Ref != "refs/heads/main" || !push.Created || len(push.Commits) != 1 || push.Commits[0].Author.Username != "octocat" || push.Commits[0].Added[0] != "README.md" {
+		t.Errorf("unexpected push event %+v", push)
+	}
+	if push.HeadCommit == nil || push.HeadCommit.Message != "Initial commit" {
+		t.Errorf("unexpected head commit %+v", push.HeadCommit)
+	}
+	if push.Repository.FullName != "octo/repo" || push.Sender.Login != "octocat" {
+		t.Errorf("unexpected repository or sender %+v %+v", push.Repository, push.Sender)
+	}
+
+	event, err := c.Event()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := event.(*github.PushEvent); !ok {
+		t.Errorf("expected *PushEvent, got %T", event)
+	}
+}
+
+func TestEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		check   func(t *testing.T, event any)
+	}{
+		{"pull_request_target", `{"action":"labeled","number":5,"label":{"name":"safe"},"pull_request":{"number":5,"head":{"ref":"feature","sha":"abc","repo":{"full_name":"fork/repo"}},"base":{"ref":"main"}}}`, func(t *testing.T, event any) {
+			e := event.(*github.PullRequestEvent)
+			if e.Number != 5 || e.Label.Name != "safe" || e.PullRequest.Head.Repo.FullName != "fork/repo" || e.PullRequest.Base.Ref != "main" {
+				t.Errorf("unexpected event %+v", e)
+			}
+		}},
+		{"issues", `{"action":"opened","issue":{"number":3,"title":"Bug","labels":[{"name":"bug"}]}}`, func(t *testing.T, event any) {
+			e := event.(*github.IssuesEvent)
+			if e.Issue.Number != 3 || e.Issue.Labels[0].Name != "bug" || e.Issue.PullRequest != nil {
+				t.Errorf("unexpected event %+v", e)
+			}
+		}},
+		{"issue_comment", `{"action":"created","issue":{"number":4,"pull_request":{"url":"u"}},"comment":{"body":"/deploy","author_association":"MEMBER"}}`, func(t *testing.T, event any) {
+			e := event.(*github.IssueCommentEvent)
+			if e.Comment.Body != "/deploy" || e.Issue.PullRequest == nil {
+				t.Errorf("unexpected event %+v", e)
+			}
+		}},
+		{"release", `{"action":"published","release":{"tag_name":"v1.2.3","prerelease":false,"assets":[{"name":"a.zip","size":10}]}}`, func(t *testing.T, event any) {
+			e := event.(*github.ReleaseEvent)
+			if e.Release.TagName != "v1.2.3" || e.Release.Assets[0].Size != 10 {
+				t.Errorf("unexpected event %+v", e)
+			}
+		}},
+		{"workflow_dispatch", `{"inputs":{"environment":"prod","dry-run":true},"ref":"refs/heads/main","workflow":".github/workflows/deploy.yml"}`, func(t *testing.T, event any) {
+			e := event.(*github.WorkflowDispatchEvent)
+			if e.Inputs["environment"] != "prod" || e.Inputs["dry-run"] != true || e.Workflow != ".github/workflows/deploy.yml" {
+				t.Errorf("unexpected event %+v", e)
+			}
+		}},
+		{"workflow_run", `{"action":"completed","workflow_run":{"id":9,"conclusion":"success","head_sha":"abc","pull_requests":[{"number":5}]},"workflow":{"name":"CI"}}`, func(t *testing.T, event any) {
+			e := event.(*github.WorkflowRunEvent)
+			if e.WorkflowRun.ID != 9 || *e.WorkflowRun.Conclusion != "success" || e.WorkflowRun.PullRequests[0].Number != 5 || e.Workflow.Name != "CI" {
+				t.Errorf("unexpected event %+v", e)
+			}
+		}},
+		{"merge_group", `{"action":"checks_requested","merge_group":{"head_sha":"abc","head_ref":"refs/heads/gh-readonly-queue/main/pr-5-def","base_ref":"refs/heads/main"}}`, func(t *testing.T, event any) {
+			e := event.(*github.MergeGroupEvent)
+			if e.MergeGroup.HeadSHA != "abc" || e.MergeGroup.BaseRef != "refs/heads/main" {
+				t.Errorf("unexpected event %+v", e)
+			}
+		}},
+		{"schedule", `{"schedule":"0 0 * * *"}`, func(t *testing.T, event any) {
+			e := event.(*github.ScheduleEvent)
+			if e.Schedule != "0 0 * * *" {
+				t.Errorf("unexpected event %+v", e)
+			}
+		}},
+		{"deployment", `{"deployment":{"id":1}}`, func(t *testing.T, event any) {
+			e := event.(map[string]any)
+			if _, ok := e["deployment"]; !ok {
+				t.Errorf("unexpected event %+v", e)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEvent(t, tt.name, tt.payload)
+			c, err := github.NewContext()
+			if err != nil {
+				t.Fatal(err)
+			}
+			event, err := c.Event()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, event)
+		})
+	}
+}
+
+func TestPayloadFromMap(t *testing.T) {
+	c := &github.Context{EventName: "schedule", Payload: map[string]any{"schedule": "*/5 * * * *"}}
+	e, err := github.Payload[github.ScheduleEvent](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Schedule != "*/5 * * * *" {
+		t.Errorf("expected %q, got %q", "*/5 * * * *", e.Schedule)
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/jcbhmr/go-toolkit/actionsgithub
+
+go 1.23
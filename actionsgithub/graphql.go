@@ -0,0 +1,248 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"strings"
+)
+
+// GraphQLURL returns the URL GraphQL queries are sent to.
+func (c *Client) GraphQLURL() string {
+	return c.graphQLURL
+}
+
+// graphQLURLFor derives the GraphQL endpoint from a REST base URL:
+// https://<host>/api/v3 becomes https://<host>/api/graphql on GitHub
+// Enterprise Server, https://api.github.com becomes
+// https://api.github.com/graphql.
+func graphQLURLFor(baseURL string) string {
+	if root, ok := strings.CutSuffix(baseURL, "/api/v3"); ok {
+		return root + "/api/graphql"
+	}
+	return baseURL + "/graphql"
+}
+
+// GraphQL runs query with variables and decodes its data into out, a
+// pointer. When the response has errors, out still gets whatever data came
+// back and the error is a *GraphQLError.
+func (c *Client) GraphQL(ctx context.Context, query string, variables map[string]any, out any) error {
+	body := map[string]any{"query": query}
+	if variables != nil {
+		body["variables"] = variables
+	}
+	req, err := c.NewRequest(ctx, "POST", c.graphQLURL, body)
+	if err != nil {
+		return err
+	}
+	_, data, err := c.Send(req)
+	if err != nil {
+		return err
+	}
+	var res struct {
+		Data   json.RawMessage      `json:"data"`
+		Errors []GraphQLErrorDetail `json:"errors"`
+	}
+	err = json.Unmarshal(data, &res)
+	if err != nil {
+		return fmt.Errorf("graphql: decoding response: %w", err)
+	}
+	if out != nil && len(res.Data) > 0 && !bytes.Equal(res.Data, []byte("null")) {
+		err = json.Unmarshal(res.Data, out)
+		if err != nil {
+			return fmt.Errorf("graphql: decoding data: %w", err)
+		}
+	}
+	if len(res.Errors) > 0 {
+		return &GraphQLError{Errors: res.Errors}
+	}
+	return nil
+}
+
+// GraphQLError holds the errors array of a GraphQL response.
+type GraphQLError struct {
+	Errors []GraphQLErrorDetail
+}
+
+type GraphQLErrorDetail struct {
+	Message string `json:"message"`
+	// e.g. NOT_FOUND, FORBIDDEN or RATE_LIMITED.
+	Type string `json:"type"`
+	// The field the error is about, as field names and list indexes.
+	Path      []any `json:"path"`
+	Locations []struct {
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	} `json:"locations"`
+	Extensions map[string]any `json:"extensions"`
+}
+
+func (e *GraphQLError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, detail := range e.Errors {
+		messages[i] = detail.Message
+		if len(detail.Path) > 0 {
+			path := make([]string, len(detail.Path))
+			for j, p := range detail.Path {
+				path[j] = fmt.Sprint(p)
+			}
+			messages[i] = strings.Join(path, ".") + ": " + messages[i]
+		}
+	}
+	return "graphql: " + strings.Join(messages, "; ")
+}
+
+// HasType reports whether any of the errors has type t, such as NOT_FOUND.
+func (e *GraphQLError) HasType(t string) bool {
+	for _, detail := range e.Errors {
+		if detail.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+type PageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor"`
+}
+
+// Connection is a page of a GraphQL connection. Queries select either nodes
+// or edges { node }.
+type Connection[T any] struct {
+	TotalCount int `json:"totalCount"`
+	Nodes      []T `json:"nodes"`
+	Edges      []struct {
+		Cursor string `json:"cursor"`
+		Node   T      `json:"node"`
+	} `json:"edges"`
+	PageInfo PageInfo `json:"pageInfo"`
+}
+
+// Items returns the nodes of the page, from nodes or else edges.
+func (c *Connection[T]) Items() []T {
+	if c.Nodes != nil {
+		return c.Nodes
+	}
+	items := make([]T, len(c.Edges))
+	for i, edge := range c.Edges {
+		items[i] = edge.Node
+	}
+	return items
+}
+
+// PaginateGraphQL lists every node of the connection at path in the data of
+// query, e.g. "repository", "pullRequest", "reviewThreads". The query takes
+// the page's cursor as $cursor and selects pageInfo { hasNextPage endCursor }
+// on the connection:
+//
+//	query($owner: String!, $repo: String!, $cursor: String) {
+//	  repository(owner: $owner, name: $repo) {
+//	    discussions(first: 100, after: $cursor) {
+//	      nodes { title }
+//	      pageInfo { hasNextPage endCursor }
+//	    }
+//	  }
+//	}
+//
+// Stopping the loop stops fetching pages. The first error ends the sequence.
+func PaginateGraphQL[T any](ctx context.Context, c *Client, query string, variables map[string]any, path ...string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		if len(path) == 0 {
+			yield(zero, fmt.Errorf("graphql: no connection path"))
+			return
+		}
+		vars := make(map[string]any, len(variables)+1)
+		for k, v := range variables {
+			vars[k] = v
+		}
+		for {
+			var data map[string]json.RawMessage
+			err := c.GraphQL(ctx, query, vars, &data)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			connection, err := connectionAt[T](data, path)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			for _, item := range connection.Items() {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if !connection.PageInfo.HasNextPage || connection.PageInfo.EndCursor == nil {
+				return
+			}
+			vars["cursor"] = *connection.PageInfo.EndCursor
+		}
+	}
+}
+
+func connectionAt[T any](data map[string]json.RawMessage, path []string) (*Connection[T], error) {
+	raw := data[path[0]]
+	for i, key := range path[1:] {
+		var object map[string]json.RawMessage
+		err := json.Unmarshal(raw, &object)
+		if err != nil || object == nil {
+			return nil, fmt.Errorf("graphql: no object at %s", strings.Join(path[:i+1], "."))
+		}
+		raw = object[key]
+	}
+	connection := &Connection[T]{}
+	err := json.Unmarshal(raw, connection)
+	if err != nil || raw == nil || bytes.Equal(raw, []byte("null")) {
+		return nil, fmt.Errorf("graphql: no connection at %s", strings.Join(path, "."))
+	}
+	return connection, nil
+}
+
+// isMutation reports whether a GraphQL document has a mutation operation,
+// looking for the mutation keyword outside of selection sets, strings and
+// comments.
+func isMutation(query string) bool {
+	depth := 0
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '#':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(query[i:], `"""`):
+			end := strings.Index(query[i+3:], `"""`)
+			if end < 0 {
+				return false
+			}
+			i += 3 + end + 2
+		case c == '"':
+			for i++; i < len(query) && query[i] != '"'; i++ {
+				if query[i] == '\\' {
+					i++
+				}
+			}
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case isNameStart(c):
+			start := i
+			for i+1 < len(query) && (isNameStart(query[i+1]) || query[i+1] >= '0' && query[i+1] <= '9') {
+				i++
+			}
+			// $mutation would be a variable.
+			if depth == 0 && query[start:i+1] == "mutation" && (start == 0 || query[start-1] != '$') {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
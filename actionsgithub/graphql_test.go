@@ -0,0 +1,149 @@
+package github_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	github "github.com/jcbhmr/go-toolkit/actionsgithub"
+)
+
+func TestGraphQL(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/api/graphql" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var body struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Variables["name"] {
+		case "repo":
+			w.Write([]byte(`{"data":{"repository":{"id":"R_1","stargazerCount":42}}}`))
+		default:
+			w.Write([]byte(`{"data":{"repository":null},"errors":[{"type":"NOT_FOUND","path":["repository"],"message":"Could not resolve to a Repository with the name 'octo/missing'."}]}`))
+		}
+	}))
+	ctx := context.Background()
+	query := `query($owner: String!, $name: String!) { repository(owner: $owner, name: $name) { id stargazerCount } }`
+
+	var out struct {
+		Repository *struct {
+			ID             string `json:"id"`
+			StargazerCount int    `json:"stargazerCount"`
+		} `json:"repository"`
+	}
+	err := c.GraphQL(ctx, query, map[string]any{"owner": "octo", "name": "repo"}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Repository == nil || out.Repository.ID != "R_1" || out.Repository.StargazerCount != 42 {
+		t.Errorf("unexpected data %+v", out.Repository)
+	}
+
+	out.Repository = nil
+	err = c.GraphQL(ctx, query, map[string]any{"owner": "octo", "name": "missing"}, &out)
+	var gqlErr *github.GraphQLError
+	if !errors.As(err, &gqlErr) {
+		t.Fatalf("expected *GraphQLError, got %T %v", err, err)
+	}
+	if !gqlErr.HasType("NOT_FOUND") || len(gqlErr.Errors) != 1 {
+		t.Errorf("unexpected errors %+v", gqlErr.Errors)
+	}
+	expected := "graphql: repository: Could not resolve to a Repository with the name 'octo/missing'."
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestGraphQLURL(t *testing.T) {
+	t.Setenv("GITHUB_GRAPHQL_URL", "https://ghes.example.com/api/graphql")
+	c, err := github.NewClient("", github.ClientOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.GraphQLURL() != "https://ghes.example.com/api/graphql" {
+		t.Errorf("unexpected GraphQL URL %q", c.GraphQLURL())
+	}
+
+	c, err = github.NewClient("", github.ClientOptions{BaseURL: ptr("https://api.github.com")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.GraphQLURL() != "https://api.github.com/graphql" {
+		t.Errorf("unexpected GraphQL URL %q", c.GraphQLURL())
+	}
+
+	c, err = github.NewClient("ghs_token", github.ClientOptions{BaseURL: ptr("https://ghes.example.com/api/v3"), GraphQLURL: ptr("https://graphql.example.com/")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := c.NewRequest(context.Background(), "POST", c.GraphQLURL(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer ghs_token" {
+		t.Errorf("expected the token to be sent to the GraphQL URL, got %q", got)
+	}
+
+	_, err = github.NewClient("", github.ClientOptions{GraphQLURL: ptr("file:///etc/passwd")})
+	if err == nil {
+		t.Error("expected an error for a non-HTTP GraphQL URL")
+	}
+	t.Setenv("GITHUB_GRAPHQL_URL", "ftp://example.com/graphql")
+	_, err = github.NewClient("", github.ClientOptions{})
+	if err == nil {
+		t.Error("expected an error for a non-HTTP GITHUB_GRAPHQL_URL")
+	}
+}
+
+func TestPaginateGraphQL(t *testing.T) {
+	requests := 0
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var body struct {
+			Variables map[string]any `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Variables["number"] != float64(7) {
+			t.Errorf("expected the caller's variables, got %v", body.Variables)
+		}
+		switch body.Variables["cursor"] {
+		case nil:
+			w.Write([]byte(`{"data":{"repository":{"pullRequest":{"reviewThreads":{"nodes":[{"id":"T_1"},{"id":"T_2"}],"pageInfo":{"hasNextPage":true,"endCursor":"c2"}}}}}}`))
+		case "c2":
+			w.Write([]byte(`{"data":{"repository":{"pullRequest":{"reviewThreads":{"edges":[{"node":{"id":"T_3"}}],"pageInfo":{"hasNextPage":false,"endCursor":"c3"}}}}}}`))
+		default:
+			t.Errorf("unexpected cursor %v", body.Variables["cursor"])
+		}
+	}))
+	ctx := context.Background()
+
+	type thread struct {
+		ID string `json:"id"`
+	}
+	path := []string{"repository", "pullRequest", "reviewThreads"}
+	threads, err := github.Collect(github.PaginateGraphQL[thread](ctx, c, "query", map[string]any{"number": 7}, path...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(threads) != 3 || threads[0].ID != "T_1" || threads[2].ID != "T_3" {
+		t.Errorf("unexpected threads %v", threads)
+	}
+
+	requests = 0
+	for range github.PaginateGraphQL[thread](ctx, c, "query", map[string]any{"number": 7}, path...) {
+		break
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request after breaking, got %d", requests)
+	}
+
+	_, err = github.Collect(github.PaginateGraphQL[thread](ctx, c, "query", map[string]any{"number": 7}, "repository", "missing"))
+	if err == nil {
+		t.Error("expected an error for a missing connection")
+	}
+}
@@ -0,0 +1,114 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"regexp"
+)
+
+// Paginate lists every item of a list endpoint, following the rel="next"
+// Link of each page. Pass per_page in path to fetch fewer, larger pages.
+// Endpoints that wrap their items in an object, like
+// {"total_count": 2, "workflow_runs": [...]}, are unwrapped. Stopping the
+// loop stops fetching pages. The first error ends the sequence.
+//
+//	for run, err := range github.Paginate[github.WorkflowRun](ctx, client, "/repos/octo/repo/actions/runs?per_page=100") {
+func Paginate[T any](ctx context.Context, c *Client, path string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		for next := path; next != ""; {
+			req, err := c.NewRequest(ctx, "GET", next, nil)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			res, body, err := c.Send(req)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			items, err := pageItems[T](body)
+			if err != nil {
+				yield(zero, fmt.Errorf("GET %s: decoding response: %w", req.URL.Path, err))
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			next = nextLink(res.Header)
+		}
+	}
+}
+
+// Collect gathers a sequence such as Paginate's into a slice, stopping at
+// the first error.
+func Collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	var items []T
+	for item, err := range seq {
+		if err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// pageItems decodes a page that is either an array or an object with an
+// array field besides the total_count, incomplete_results and
+// repository_selection metadata Octokit also ignores. If the object has
+// several, the first one in the document is used.
+func pageItems[T any](body []byte) ([]T, error) {
+	var items []T
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		err := json.Unmarshal(body, &items)
+		return items, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token != json.Delim('{') {
+		return nil, fmt.Errorf("expected an array or an object with an array field")
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		err = dec.Decode(&value)
+		if err != nil {
+			return nil, err
+		}
+		switch token {
+		case "total_count", "incomplete_results", "repository_selection":
+			continue
+		}
+		if len(value) > 0 && value[0] == '[' {
+			err = json.Unmarshal(value, &items)
+			return items, err
+		}
+	}
+	return nil, fmt.Errorf("expected an array or an object with an array field")
+}
+
+var linkRegExp = regexp.MustCompile(`<([^>]*)>\s*;\s*rel="?([^";]*)"?`)
+
+func nextLink(header http.Header) string {
+	for _, value := range header.Values("Link") {
+		for _, m := range linkRegExp.FindAllStringSubmatch(value, -1) {
+			if m[2] == "next" {
+				return m[1]
+			}
+		}
+	}
+	return ""
+}
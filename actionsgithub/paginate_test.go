@@ -0,0 +1,106 @@
+package github_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	github "github.com/jcbhmr/go-toolkit/actionsgithub"
+)
+
+func TestPaginate(t *testing.T) {
+	requests := 0
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page := r.URL.Query().Get("page")
+		next := ""
+		switch page {
+		case "", "1":
+			page = "1"
+			next = "2"
+		case "2":
+			next = "3"
+		}
+		if next != "" {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?page=%s>; rel="next", <http://%s%s?page=3>; rel="last"`, r.Host, r.URL.Path, next, r.Host, r.URL.Path))
+		}
+		switch r.URL.Path {
+		case "/api/v3/repos/octo/repo/pulls/1/files":
+			fmt.Fprintf(w, `[{"filename":"%s-a"},{"filename":"%s-b"}]`, page, page)
+		case "/api/v3/repos/octo/repo/actions/runs":
+			fmt.Fprintf(w, `{"total_count":3,"workflow_runs":[{"id":%s}]}`, page)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	ctx := context.Background()
+
+	type file struct {
+		Filename string `json:"filename"`
+	}
+	files, err := github.Collect(github.Paginate[file](ctx, c, "/repos/octo/repo/pulls/1/files"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 6 || files[0].Filename != "1-a" || files[5].Filename != "3-b" {
+		t.Errorf("unexpected files %v", files)
+	}
+
+	runs, err := github.Collect(github.Paginate[github.WorkflowRun](ctx, c, "/repos/octo/repo/actions/runs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 3 || runs[0].ID != 1 || runs[2].ID != 3 {
+		t.Errorf("unexpected runs %v", runs)
+	}
+
+	requests = 0
+	for f, err := range github.Paginate[file](ctx, c, "/repos/octo/repo/pulls/1/files") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Filename == "1-b" {
+			break
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request after breaking on the first page, got %d", requests)
+	}
+}
+
+func TestPaginateError(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?page=2>; rel="next"`, r.Host, r.URL.Path))
+		w.Write([]byte(`[1, 2]`))
+	}))
+
+	items, err := github.Collect(github.Paginate[int](context.Background(), c, "/items"))
+	if err == nil {
+		t.Fatal("expected an error for the failing page")
+	}
+	if len(items) != 2 {
+		t.Errorf("expected the items before the error, got %v", items)
+	}
+}
+
+func TestPaginateFirstArray(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"total_count":2,"meta":{"tags":["x"]},"items":[1,2],"warnings":[],"incomplete_results":false}`))
+	}))
+
+	// Map order is random, so decode a few times.
+	for i := 0; i < 20; i++ {
+		items, err := github.Collect(github.Paginate[int](context.Background(), c, "/search"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) != 2 || items[0] != 1 || items[1] != 2 {
+			t.Fatalf("expected the first array field, got %v", items)
+		}
+	}
+}
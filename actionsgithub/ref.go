@@ -0,0 +1,160 @@
+package github
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type RefKind string
+
+const (
+	RefBranch      RefKind = "branch"
+	RefTag         RefKind = "tag"
+	RefPullRequest RefKind = "pull_request"
+	RefRemote      RefKind = "remote"
+	// RefMergeQueue is the temporary branch of a merge queue entry,
+	// refs/heads/gh-readonly-queue/<base branch>/pr-<number>-<sha>.
+	RefMergeQueue RefKind = "merge_queue"
+	RefOther      RefKind = "other"
+)
+
+// Ref is a parsed fully-qualified Git ref such as GITHUB_REF.
+type Ref struct {
+	// The ref as given, e.g. refs/heads/main.
+	Full string
+	Kind RefKind
+	// The ref without its refs/<type>/ prefix, e.g. main, v1.2.3, 42/merge
+	// or origin/main.
+	Name string
+
+	// The branch of refs/heads and refs/remotes refs, or the base branch of
+	// merge queue refs.
+	Branch string
+	Tag    string
+	// The remote of refs/remotes refs.
+	Remote string
+
+	// The pull request number of refs/pull and merge queue refs.
+	PullRequest int
+	// "merge" or "head" for refs/pull refs.
+	PullRequestRef string
+	// The head commit of merge queue refs.
+	MergeQueueSHA string
+
+	// Version is set for tags that are semantic versions, with or without a
+	// leading v.
+	Version *Version
+}
+
+var (
+	pullRefRegExp       = regexp.MustCompile(`^(\d+)/(merge|head)$`)
+	mergeQueueRefRegExp = regexp.MustCompile(`^gh-readonly-queue/(.+)/pr-(\d+)-([0-9a-f]+)$`)
+)
+
+// ParseRef parses a fully-qualified ref. Refs under refs/ that aren't
+// branches, tags, pull requests or remote branches have Kind RefOther.
+func ParseRef(ref string) (Ref, error) {
+	rest, ok := strings.CutPrefix(ref, "refs/")
+	if !ok || rest == "" {
+		return Ref{}, fmt.Errorf("invalid ref %q: expected refs/...", ref)
+	}
+	r := Ref{Full: ref, Kind: RefOther, Name: rest}
+	kind, name, _ := strings.Cut(rest, "/")
+	if name == "" {
+		return r, nil
+	}
+	switch kind {
+	case "heads":
+		r.Name = name
+		if m := mergeQueueRefRegExp.FindStringSubmatch(name); m != nil {
+			r.Kind = RefMergeQueue
+			r.Branch = m[1]
+			r.PullRequest, _ = strconv.Atoi(m[2])
+			r.MergeQueueSHA = m[3]
+		} else {
+			r.Kind = RefBranch
+			r.Branch = name
+		}
+	case "tags":
+		r.Kind = RefTag
+		r.Name = name
+		r.Tag = name
+		if v, err := ParseVersion(name); err == nil {
+			r.Version = &v
+		}
+	case "pull":
+		m := pullRefRegExp.FindStringSubmatch(name)
+		if m == nil {
+			return r, nil
+		}
+		r.Kind = RefPullRequest
+		r.Name = name
+		r.PullRequest, _ = strconv.Atoi(m[1])
+		r.PullRequestRef = m[2]
+	case "remotes":
+		remote, branch, ok := strings.Cut(name, "/")
+		if !ok || branch == "" {
+			return r, nil
+		}
+		r.Kind = RefRemote
+		r.Name = name
+		r.Remote = remote
+		r.Branch = branch
+	}
+	return r, nil
+}
+
+// ParseRef parses Ref, GITHUB_REF.
+func (c *Context) ParseRef() (Ref, error) {
+	return ParseRef(c.Ref)
+}
+
+// Version is a semantic version, https://semver.org.
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	Build      string
+	// Whether it was written with a leading v.
+	V bool
+}
+
+var versionRegExp = regexp.MustCompile(`^(v?)(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+// ParseVersion parses a semantic version like 1.2.3 or v1.2.3-rc.1+build.5.
+func ParseVersion(s string) (Version, error) {
+	m := versionRegExp.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("invalid semantic version %q", s)
+	}
+	var v Version
+	var err error
+	v.V = m[1] == "v"
+	for i, field := range []*int{&v.Major, &v.Minor, &v.Patch} {
+		*field, err = strconv.Atoi(m[i+2])
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid semantic version %q: %w", s, err)
+		}
+	}
+	v.Prerelease = m[5]
+	v.Build = m[6]
+	return v, nil
+}
+
+func (v Version) String() string {
+	var b strings.Builder
+	if v.V {
+		b.WriteByte('v')
+	}
+	fmt.Fprintf(&b, "%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		b.WriteString("-" + v.Prerelease)
+	}
+	if v.Build != "" {
+		b.WriteString("+" + v.Build)
+	}
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package github_test
+
+import (
+	"reflect"
+	"testing"
+
+	github "github.com/jcbhmr/go-toolkit/actionsgithub"
+)
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		ref      string
+		expected github.Ref
+	}{
+		{"refs/heads/main", github.Ref{Kind: github.RefBranch, Name: "main", Branch: "main"}},
+		{"refs/heads/feature/x", github.Ref{Kind: github.RefBranch, Name: "feature/x", Branch: "feature/x"}},
+		{"refs/tags/v1.2.3", github.Ref{Kind: github.RefTag, Name: "v1.2.3", Tag: "v1.2.3", Version: &github.Version{Major: 1, Minor: 2, Patch: 3, V: true}}},
+		{"refs/tags/2.0.0-rc.1+build.7", github.Ref{Kind: github.RefTag, Name: "2.0.0-rc.1+build.7", Tag: "2.0.0-rc.1+build.7", Version: &github.Version{Major: 2, Prerelease: "rc.1", Build: "build.7"}}},
+		{"refs/tags/v1", github.Ref{Kind: github.RefTag, Name: "v1", Tag: "v1"}},
+		{"refs/tags/release-2024", github.Ref{Kind: github.RefTag, Name: "release-2024", Tag: "release-2024"}},
+		{"refs/pull/42/merge", github.Ref{Kind: github.RefPullRequest, Name: "42/merge", PullRequest: 42, PullRequestRef: "merge"}},
+		{"refs/pull/42/head", github.Ref{Kind: github.RefPullRequest, Name: "42/head", PullRequest: 42, PullRequestRef: "head"}},
+		{"refs/pull/42/other", github.Ref{Kind: github.RefOther, Name: "pull/42/other"}},
+		{"refs/remotes/origin/main", github.Ref{Kind: github.RefRemote, Name: "origin/main", Remote: "origin", Branch: "main"}},
+		{"refs/heads/gh-readonly-queue/release/v2/pr-17-4f2a9c0e8b1d", github.Ref{Kind: github.RefMergeQueue, Name: "gh-readonly-queue/release/v2/pr-17-4f2a9c0e8b1d", Branch: "release/v2", PullRequest: 17, MergeQueueSHA: "4f2a9c0e8b1d"}},
+		{"refs/notes/commits", github.Ref{Kind: github.RefOther, Name: "notes/commits"}},
+	}
+	for _, tt := range tests {
+		got, err := github.ParseRef(tt.ref)
+		if err != nil {
+			t.Errorf("%s: %v", tt.ref, err)
+			continue
+		}
+		tt.expected.Full = tt.ref
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: expected %+v, got %+v", tt.ref, tt.expected, got)
+		}
+	}
+
+	for _, ref := range []string{"", "main", "refs/", "heads/main"} {
+		_, err := github.ParseRef(ref)
+		if err == nil {
+			t.Errorf("%q: expected an error", ref)
+		}
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	for _, s := range []string{"1.2.3", "v0.0.1", "v1.0.0-alpha.1", "1.0.0+20240101", "v10.20.30-rc.1+sha.abc"} {
+		v, err := github.ParseVersion(s)
+		if err != nil {
+			t.Errorf("%s: %v", s, err)
+			continue
+		}
+		if v.String() != s {
+			t.Errorf("expected %q, got %q", s, v.String())
+		}
+	}
+	for _, s := range []string{"1.2", "v01.2.3", "1.2.3-", "1.2.3-01", "version1"} {
+		_, err := github.ParseVersion(s)
+		if err == nil {
+			t.Errorf("%s: expected an error", s)
+		}
+	}
+}
+
+func TestContextParseRef(t *testing.T) {
+	c := &github.Context{Ref: "refs/pull/7/merge"}
+	ref, err := c.ParseRef()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.PullRequest != 7 {
+		t.Errorf("expected 7, got %d", ref.PullRequest)
+	}
+}
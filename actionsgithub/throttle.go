@@ -0,0 +1,305 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ThrottleOptions configures how the client stays within GitHub's rate
+// limits, following
+// https://docs.github.com/rest/using-the-rest-api/best-practices-for-using-the-rest-api.
+type ThrottleOptions struct {
+	// Turns throttling off, sending every request as soon as it is made and
+	// never retrying.
+	Disabled *bool
+	// Requests wait for the rate limit to reset once this few requests
+	// remain. Defaults to 10.
+	MinRemaining *int
+	// The longest the client waits for a rate limit, before a request or
+	// before retrying one. Requests that would need to wait longer fail
+	// instead. Defaults to one minute.
+	MaxWait *time.Duration
+	// How many times a rate-limited request is retried. Defaults to 3.
+	MaxRetries *int
+	// The minimum spacing between mutating requests, which are sent one at a
+	// time: POST, PATCH, PUT and DELETE requests, except for GraphQL queries,
+	// which only count when they are mutations. Defaults to one second.
+	WriteInterval *time.Duration
+}
+
+// RateLimit is the primary rate limit of a resource as of the last response,
+// read from its x-ratelimit-* headers.
+type RateLimit struct {
+	// The resource the limit applies to, e.g. "core", "search" or "graphql".
+	Resource  string
+	Limit     int
+	Remaining int
+	Used      int
+	Reset     time.Time
+}
+
+type throttle struct {
+	disabled      bool
+	minRemaining  int
+	maxWait       time.Duration
+	maxRetries    int
+	writeInterval time.Duration
+
+	mu         sync.Mutex
+	rateLimits map[string]RateLimit
+
+	writeMu   sync.Mutex
+	lastWrite time.Time
+}
+
+func newThrottle(options ThrottleOptions) *throttle {
+	return &throttle{
+		disabled:      ptrOr(options.Disabled, false),
+		minRemaining:  ptrOr(options.MinRemaining, 10),
+		maxWait:       ptrOr(options.MaxWait, time.Minute),
+		maxRetries:    ptrOr(options.MaxRetries, 3),
+		writeInterval: ptrOr(options.WriteInterval, time.Second),
+		rateLimits:    map[string]RateLimit{},
+	}
+}
+
+func ptrOr[T any](v *T, fallback T) T {
+	if v == nil {
+		return fallback
+	}
+	return *v
+}
+
+// RateLimit returns the last seen rate limit of resource. It is false until
+// a response for the resource has been received.
+func (c *Client) RateLimit(resource string) (RateLimit, bool) {
+	c.throttle.mu.Lock()
+	defer c.throttle.mu.Unlock()
+	limit, ok := c.throttle.rateLimits[resource]
+	return limit, ok
+}
+
+// Send sends req and returns the response and its body. Responses outside
+// 2xx are returned as an *APIError.
+//
+// Unless throttling is disabled, Send waits when the rate limit is nearly
+// used up, retries responses that hit a rate limit after the time GitHub
+// asks for and spaces out mutating requests.
+func (c *Client) Send(req *http.Request) (*http.Response, []byte, error) {
+	t := c.throttle
+	if t.disabled {
+		return c.send(req)
+	}
+	ctx := req.Context()
+	write := c.isWrite(req)
+
+	for retries := 0; ; retries++ {
+		err := c.waitForRateLimit(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		if write {
+			err = t.beginWrite(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		res, body, err := c.send(req)
+		if write {
+			t.endWrite()
+		}
+		if res != nil {
+			t.update(res.Header)
+		}
+		apiErr, ok := err.(*APIError)
+		if !ok || !apiErr.rateLimited() {
+			return res, body, err
+		}
+		wait := apiErr.retryAfter(time.Now())
+		if retries >= t.maxRetries || wait > t.maxWait {
+			c.debug(fmt.Sprintf("%s %s hit a rate limit, not retrying after %d retries and with a wait of %s", req.Method, req.URL, retries, wait))
+			return res, body, err
+		}
+		c.debug(fmt.Sprintf("%s %s hit a rate limit, retrying in %s", req.Method, req.URL, wait))
+		err = sleep(ctx, wait)
+		if err != nil {
+			return nil, nil, err
+		}
+		req, err = rewind(req)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// beginWrite waits for the writes before it and for writeInterval to pass
+// since the last one. Every successful call must be followed by endWrite.
+func (t *throttle) beginWrite(ctx context.Context) error {
+	t.writeMu.Lock()
+	if wait := t.writeInterval - time.Since(t.lastWrite); wait > 0 {
+		err := sleep(ctx, wait)
+		if err != nil {
+			t.writeMu.Unlock()
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *throttle) endWrite() {
+	t.lastWrite = time.Now()
+	t.writeMu.Unlock()
+}
+
+// waitForRateLimit waits for the rate limit of req's resource to reset if at
+// most minRemaining requests remain and the reset is within maxWait.
+func (c *Client) waitForRateLimit(req *http.Request) error {
+	t := c.throttle
+	resource := resourceOf(req)
+	limit, ok := c.RateLimit(resource)
+	if !ok || limit.Remaining > t.minRemaining {
+		return nil
+	}
+	wait := time.Until(limit.Reset)
+	if wait <= 0 {
+		return nil
+	}
+	if wait > t.maxWait {
+		c.debug(fmt.Sprintf("%d %s requests remain until %s, not waiting", limit.Remaining, resource, limit.Reset.Format(time.RFC3339)))
+		return nil
+	}
+	c.debug(fmt.Sprintf("%d %s requests remain, waiting %s for the rate limit to reset", limit.Remaining, resource, wait))
+	return sleep(req.Context(), wait)
+}
+
+func (t *throttle) update(header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-Ratelimit-Remaining"))
+	if err != nil {
+		return
+	}
+	limit := RateLimit{
+		Resource:  header.Get("X-Ratelimit-Resource"),
+		Remaining: remaining,
+	}
+	if limit.Resource == "" {
+		limit.Resource = "core"
+	}
+	limit.Limit, _ = strconv.Atoi(header.Get("X-Ratelimit-Limit"))
+	limit.Used, _ = strconv.Atoi(header.Get("X-Ratelimit-Used"))
+	if reset, err := strconv.ParseInt(header.Get("X-Ratelimit-Reset"), 10, 64); err == nil {
+		limit.Reset = time.Unix(reset, 0)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rateLimits[limit.Resource] = limit
+}
+
+// resourceOf guesses the rate limit resource of req before it is sent.
+func resourceOf(req *http.Request) string {
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/graphql"):
+		return "graphql"
+	case strings.Contains(req.URL.Path, "/search/"):
+		return "search"
+	default:
+		return "core"
+	}
+}
+
+// rateLimited reports whether e is a primary or secondary rate limit error.
+func (e *APIError) rateLimited() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		return e.Header.Get("Retry-After") != "" ||
+			e.Header.Get("X-Ratelimit-Remaining") == "0" ||
+			strings.Contains(strings.ToLower(e.Message), "rate limit")
+	default:
+		return false
+	}
+}
+
+// retryAfter is how long to wait before retrying a rate limit error: the
+// Retry-After header, else until the primary rate limit resets, else the
+// minute GitHub asks for after secondary rate limits.
+func (e *APIError) retryAfter(now time.Time) time.Duration {
+	if value := e.Header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+		if date, err := http.ParseTime(value); err == nil {
+			return max(date.Sub(now), 0)
+		}
+	}
+	if e.Header.Get("X-Ratelimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(e.Header.Get("X-Ratelimit-Reset"), 10, 64); err == nil {
+			return max(time.Unix(reset, 0).Sub(now), 0)
+		}
+	}
+	return time.Minute
+}
+
+// isWrite reports whether req mutates anything and so must be spaced out.
+// GraphQL requests are always POSTs, so their query is checked for a
+// mutation instead.
+func (c *Client) isWrite(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodPost, http.MethodPatch, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	if resourceOf(req) != "graphql" {
+		return true
+	}
+	if req.GetBody == nil {
+		return true
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return true
+	}
+	defer body.Close()
+	var payload struct {
+		Query string `json:"query"`
+	}
+	err = json.NewDecoder(body).Decode(&payload)
+	if err != nil {
+		return true
+	}
+	return isMutation(payload.Query)
+}
+
+// rewind returns a copy of req with a fresh body to send it again.
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("%s %s: cannot retry a request with a one-shot body", req.Method, req.URL)
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Body = body
+	return req, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,251 @@
+package github_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	github "github.com/jcbhmr/go-toolkit/actionsgithub"
+)
+
+func newThrottledClient(t *testing.T, handler http.Handler, options github.ThrottleOptions) (*github.Client, *[]string) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	var mu sync.Mutex
+	var logs []string
+	c, err := github.NewClient("", github.ClientOptions{
+		BaseURL:  ptr(server.URL),
+		Throttle: options,
+		Debug: func(message string) {
+			mu.Lock()
+			defer mu.Unlock()
+			logs = append(logs, message)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, &logs
+}
+
+func TestThrottleRetry(t *testing.T) {
+	attempts := 0
+	c, logs := newThrottledClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if r.Method == "POST" && r.ContentLength == 0 {
+			t.Errorf("expected the retried request to have a body")
+		}
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"You have exceeded a secondary rate limit."}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}), github.ThrottleOptions{WriteInterval: ptr(time.Duration(0))})
+
+	v, err := github.Do[map[string]bool](context.Background(), c, "POST", "/repos/octo/repo/issues", map[string]string{"title": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v["ok"] || attempts != 2 {
+		t.Errorf("expected a successful retry, got %v after %d attempts", v, attempts)
+	}
+	if len(*logs) != 1 || !strings.Contains((*logs)[0], "retrying in 0s") {
+		t.Errorf("unexpected debug logs %q", *logs)
+	}
+}
+
+func TestThrottleGiveUp(t *testing.T) {
+	attempts := 0
+	c, _ := newThrottledClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if r.URL.Path == "/later" {
+			w.Header().Set("Retry-After", "120")
+		} else {
+			w.Header().Set("Retry-After", "0")
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+	}), github.ThrottleOptions{MaxRetries: ptr(2)})
+	ctx := context.Background()
+
+	_, err := github.Do[any](ctx, c, "GET", "/now", nil)
+	var apiErr *github.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected a 429 *APIError, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+
+	attempts = 0
+	_, err = github.Do[any](ctx, c, "GET", "/later", nil)
+	if err == nil || attempts != 1 {
+		t.Errorf("expected a Retry-After past MaxWait to fail at once, got %v after %d attempts", err, attempts)
+	}
+}
+
+func TestThrottleRateLimit(t *testing.T) {
+	reset := time.Now().Add(time.Second).Truncate(time.Second)
+	c, logs := newThrottledClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ratelimit-Limit", "5000")
+		w.Header().Set("X-Ratelimit-Remaining", "3")
+		w.Header().Set("X-Ratelimit-Used", "4997")
+		w.Header().Set("X-Ratelimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.Header().Set("X-Ratelimit-Resource", "core")
+		w.Write([]byte(`{}`))
+	}), github.ThrottleOptions{})
+	ctx := context.Background()
+
+	_, ok := c.RateLimit("core")
+	if ok {
+		t.Error("expected no rate limit before the first response")
+	}
+	_, err := github.Do[any](ctx, c, "GET", "/rate", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limit, ok := c.RateLimit("core")
+	expected := github.RateLimit{Resource: "core", Limit: 5000, Remaining: 3, Used: 4997, Reset: reset}
+	if !ok || limit != expected {
+		t.Errorf("expected %+v, got %+v", expected, limit)
+	}
+
+	_, err = github.Do[any](ctx, c, "GET", "/rate", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Now().Before(reset) {
+		t.Errorf("expected the request to wait for the reset at %s", reset)
+	}
+	if len(*logs) != 1 || !strings.Contains((*logs)[0], "3 core requests remain, waiting") {
+		t.Errorf("unexpected debug logs %q", *logs)
+	}
+}
+
+func TestThrottleWriteInterval(t *testing.T) {
+	var mu sync.Mutex
+	var times []time.Time
+	c, _ := newThrottledClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		times = append(times, time.Now())
+	}), github.ThrottleOptions{WriteInterval: ptr(50 * time.Millisecond)})
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := github.Do[any](context.Background(), c, "PATCH", "/repos/octo/repo", map[string]string{})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(times) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(times))
+	}
+	for i := 1; i < len(times); i++ {
+		if d := times[i].Sub(times[i-1]); d < 50*time.Millisecond {
+			t.Errorf("expected writes 50ms apart, got %s", d)
+		}
+	}
+}
+
+func TestThrottleGraphQL(t *testing.T) {
+	var mu sync.Mutex
+	var times []time.Time
+	c, _ := newThrottledClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		times = append(times, time.Now())
+		w.Write([]byte(`{"data":{}}`))
+	}), github.ThrottleOptions{})
+	ctx := context.Background()
+
+	// Queries are POSTs too, but with the default settings they aren't
+	// spaced out like writes, even when they mention mutations.
+	queries := []string{
+		`{ viewer { login } }`,
+		`query Mutations { search(query: "mutation", type: ISSUE) { issueCount } }`,
+		"# mutation\nquery { repository(owner: \"octo\", name: \"repo\") { mutation: id } }",
+		`query($mutation: String) { viewer { login } }`,
+	}
+	start := time.Now()
+	for _, query := range queries {
+		err := c.GraphQL(ctx, query, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d >= 500*time.Millisecond {
+		t.Errorf("expected queries not to be spaced out, took %s", d)
+	}
+
+	times = nil
+	for _, query := range []string{
+		`mutation { addStar(input: {starrableId: "R_1"}) { clientMutationId } }`,
+		`fragment F on Repository { id } mutation Star($id: ID!) { addStar(input: {starrableId: $id}) { starrable { ...F } } }`,
+	} {
+		err := c.GraphQL(ctx, query, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := times[1].Sub(times[0]); d < time.Second {
+		t.Errorf("expected mutations a second apart, got %s", d)
+	}
+}
+
+func TestThrottleRetryReleasesWrites(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	limited := make(chan struct{})
+	attempts := 0
+	c, _ := newThrottledClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, r.URL.Path)
+		if r.URL.Path == "/limited" {
+			attempts++
+			if attempts == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"message":"You have exceeded a secondary rate limit."}`))
+				close(limited)
+				return
+			}
+		}
+		w.Write([]byte(`{}`))
+	}), github.ThrottleOptions{WriteInterval: ptr(time.Duration(0))})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := github.Do[any](ctx, c, "POST", "/limited", map[string]string{})
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	<-limited
+	_, err := github.Do[any](ctx, c, "POST", "/other", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	expected := []string{"/limited", "/other", "/limited"}
+	if strings.Join(order, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected other writes to go ahead while a retry waits, got %v", order)
+	}
+}